@@ -9,6 +9,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -16,12 +17,45 @@ import (
 	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
 )
 
+const (
+	BearerAuthScopes = "bearerAuth.Scopes"
+)
+
+// Defines values for ListLogsParamsTime.
+const (
+	Event    ListLogsParamsTime = "event"
+	Received ListLogsParamsTime = "received"
+)
+
+// Defines values for ListLogsParamsOrder.
+const (
+	Asc  ListLogsParamsOrder = "asc"
+	Desc ListLogsParamsOrder = "desc"
+)
+
 // ErrorResponse defines model for ErrorResponse.
 type ErrorResponse struct {
 	// Errors List of errors.
 	Errors []string `json:"errors"`
 }
 
+// IngestResult defines model for IngestResult.
+type IngestResult struct {
+	// Accepted The number of stored entries, including duplicates.
+	Accepted int `json:"accepted"`
+
+	// Duplicates IDs of entries that have already been stored. They are ignored, unless the tenant duplicate
+	// policy is last-write-wins.
+	Duplicates []string `json:"duplicates"`
+
+	// Id The ingest request identifier.
+	Id string `json:"id"`
+
+	// Rejected Entries that haven't been stored, e.g. beyond the storage quota or duplicates with different
+	// content under the conflict-error duplicate policy.
+	Rejected []RejectedEntry `json:"rejected"`
+}
+
 // Log defines model for Log.
 type Log struct {
 	// Attributes A list of dynamic attributes that Log entry can contain.
@@ -33,6 +67,12 @@ type Log struct {
 	// Message The massage of the the Log entry.
 	Message string `json:"message"`
 
+	// ReceivedAt The RFC3339 time the receiver has stored the Log entry at, it's ignored on ingestion.
+	ReceivedAt *time.Time `json:"received_at,omitempty"`
+
+	// Sequence The ingest sequence number of the Log entry, it's ignored on ingestion.
+	Sequence *int64 `json:"sequence,omitempty"`
+
 	// Severity A severity level of the Log entry.
 	Severity string `json:"severity"`
 
@@ -40,12 +80,85 @@ type Log struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// RejectedEntry defines model for RejectedEntry.
+type RejectedEntry struct {
+	// Index The index of the entry in the request.
+	Index int `json:"index"`
+
+	// Reason Why the entry hasn't been stored.
+	Reason string `json:"reason"`
+}
+
+// Forbidden defines model for Forbidden.
+type Forbidden = ErrorResponse
+
+// Unauthorized defines model for Unauthorized.
+type Unauthorized = ErrorResponse
+
 // ListLogsParams defines parameters for ListLogs.
 type ListLogsParams struct {
 	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
 	To   *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Time The time entries are filtered by `from` and `to` and sorted by: `event` is the timestamp
+	// of the entry, `received` is when the receiver has stored it.
+	Time *ListLogsParamsTime `form:"time,omitempty" json:"time,omitempty"`
+
+	// Order The sort order of entries by the time, entries with equal times are sorted in ingest order.
+	Order *ListLogsParamsOrder `form:"order,omitempty" json:"order,omitempty"`
+
+	// Severity Severities of entries, compared case-insensitively.
+	Severity *[]string `form:"severity,omitempty" json:"severity,omitempty"`
+
+	// MinSeverity The minimal severity level of entries, one of trace, debug, info, notice, warn, error, critical,
+	// alert and emergency (or their aliases information, warning, err, crit, fatal and emerg).
+	// Entries with other severities are filtered out.
+	MinSeverity *string `form:"min_severity,omitempty" json:"min_severity,omitempty"`
+
+	// Message A substring of entry messages.
+	Message *string `form:"message,omitempty" json:"message,omitempty"`
+
+	// MessageRegex A regular expression (RE2 syntax) matching entry messages.
+	MessageRegex *string `form:"message_regex,omitempty" json:"message_regex,omitempty"`
+
+	// IdPrefix A prefix of entry IDs.
+	IdPrefix *string `form:"id_prefix,omitempty" json:"id_prefix,omitempty"`
+
+	// Attr Attribute filters that entries must all match. Nested attributes are named with dots, e.g.
+	// `http.status`. A filter is either `name` (the attribute exists), `!name` (it's missing) or
+	// `name<op>value`, where op is `=`, `!=`, `<`, `<=`, `>` or `>=`. Equality matches list attributes
+	// containing the value, and comparisons require numeric values.
+	Attr *[]string `form:"attr,omitempty" json:"attr,omitempty"`
+
+	// Text A full-text search of messages by the index of their terms, which are case-insensitive words of
+	// letters and digits. Messages must contain all terms, "quoted phrases" of consecutive terms and
+	// terms with prefixes ending with `*`, e.g. `payment "connection reset" time*`.
+	Text *string `form:"text,omitempty" json:"text,omitempty"`
+
+	// Q A query combining comparisons of fields with AND, OR, NOT and parentheses, e.g.
+	// `severity>=warn AND (service:payments OR message~"timeout") AND NOT attributes.env:dev`.
+	// Fields are `id`, `message`, `severity`, `timestamp`, `received_at`, `sequence` and
+	// `attributes.<name>`, or just `<name>` for other attributes. Operators are `:` (contains
+	// for messages, equals otherwise, or the attribute exists if the value is `*`), `=`, `!=`,
+	// `~` (matches a regular expression), `<`, `<=`, `>` and `>=`, which compare severities by
+	// levels, times as RFC 3339 date-times and attributes as numbers. Values with spaces or
+	// parentheses are double-quoted. Syntax errors are reported with their columns.
+	Q *string `form:"q,omitempty" json:"q,omitempty"`
+
+	// Limit The maximal number of entries in the response.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor The opaque cursor of the next page given by the previous response. Other parameters must be
+	// the same as in the previous request.
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
 }
 
+// ListLogsParamsTime defines parameters for ListLogs.
+type ListLogsParamsTime string
+
+// ListLogsParamsOrder defines parameters for ListLogs.
+type ListLogsParamsOrder string
+
 // PostLogJSONBody defines parameters for PostLog.
 type PostLogJSONBody = []Log
 
@@ -57,6 +170,9 @@ type ServerInterface interface {
 	// Health check endpoint.
 	// (GET /health)
 	Health(w http.ResponseWriter, r *http.Request)
+	// Returns the result of a recent ingest request.
+	// (GET /v1/ingestions/{id})
+	GetIngestion(w http.ResponseWriter, r *http.Request, id string)
 	// An endpoint to retrieve logs.
 	// (GET /v1/logs)
 	ListLogs(w http.ResponseWriter, r *http.Request, params ListLogsParams)
@@ -91,11 +207,48 @@ func (siw *ServerInterfaceWrapper) Health(w http.ResponseWriter, r *http.Request
 	handler.ServeHTTP(w, r)
 }
 
+// GetIngestion operation middleware
+func (siw *ServerInterfaceWrapper) GetIngestion(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetIngestion(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // ListLogs operation middleware
 func (siw *ServerInterfaceWrapper) ListLogs(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	// Parameter object where we will unmarshal all parameters from the context
 	var params ListLogsParams
 
@@ -115,6 +268,102 @@ func (siw *ServerInterfaceWrapper) ListLogs(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// ------------- Optional query parameter "time" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "time", r.URL.Query(), &params.Time)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "time", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "order" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "order", r.URL.Query(), &params.Order)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "order", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "severity" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "severity", r.URL.Query(), &params.Severity)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "severity", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "min_severity" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "min_severity", r.URL.Query(), &params.MinSeverity)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "min_severity", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "message" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "message", r.URL.Query(), &params.Message)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "message", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "message_regex" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "message_regex", r.URL.Query(), &params.MessageRegex)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "message_regex", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "id_prefix" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "id_prefix", r.URL.Query(), &params.IdPrefix)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id_prefix", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "attr" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "attr", r.URL.Query(), &params.Attr)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "attr", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "text" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "text", r.URL.Query(), &params.Text)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "text", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "q" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "q", r.URL.Query(), &params.Q)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "q", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.ListLogs(w, r, params)
 	}))
@@ -129,6 +378,12 @@ func (siw *ServerInterfaceWrapper) ListLogs(w http.ResponseWriter, r *http.Reque
 // PostLog operation middleware
 func (siw *ServerInterfaceWrapper) PostLog(w http.ResponseWriter, r *http.Request) {
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.PostLog(w, r)
 	}))
@@ -154,6 +409,12 @@ func (siw *ServerInterfaceWrapper) GetLogsById(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetLogsById(w, r, id)
 	}))
@@ -286,6 +547,7 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 	}
 
 	m.HandleFunc("GET "+options.BaseURL+"/health", wrapper.Health)
+	m.HandleFunc("GET "+options.BaseURL+"/v1/ingestions/{id}", wrapper.GetIngestion)
 	m.HandleFunc("GET "+options.BaseURL+"/v1/logs", wrapper.ListLogs)
 	m.HandleFunc("POST "+options.BaseURL+"/v1/logs", wrapper.PostLog)
 	m.HandleFunc("GET "+options.BaseURL+"/v1/logs/{id}", wrapper.GetLogsById)
@@ -293,6 +555,10 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 	return m
 }
 
+type ForbiddenJSONResponse ErrorResponse
+
+type UnauthorizedJSONResponse ErrorResponse
+
 type HealthRequestObject struct {
 }
 
@@ -308,6 +574,50 @@ func (response Health200Response) VisitHealthResponse(w http.ResponseWriter) err
 	return nil
 }
 
+type GetIngestionRequestObject struct {
+	Id string `json:"id"`
+}
+
+type GetIngestionResponseObject interface {
+	VisitGetIngestionResponse(w http.ResponseWriter) error
+}
+
+type GetIngestion200JSONResponse IngestResult
+
+func (response GetIngestion200JSONResponse) VisitGetIngestionResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetIngestion401JSONResponse struct{ UnauthorizedJSONResponse }
+
+func (response GetIngestion401JSONResponse) VisitGetIngestionResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetIngestion403JSONResponse struct{ ForbiddenJSONResponse }
+
+func (response GetIngestion403JSONResponse) VisitGetIngestionResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetIngestion404JSONResponse ErrorResponse
+
+func (response GetIngestion404JSONResponse) VisitGetIngestionResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type ListLogsRequestObject struct {
 	Params ListLogsParams
 }
@@ -316,13 +626,45 @@ type ListLogsResponseObject interface {
 	VisitListLogsResponse(w http.ResponseWriter) error
 }
 
-type ListLogs200JSONResponse []Log
+type ListLogs200ResponseHeaders struct {
+	Link        string
+	XNextCursor string
+}
+
+type ListLogs200JSONResponse struct {
+	Body    []Log
+	Headers ListLogs200ResponseHeaders
+}
 
 func (response ListLogs200JSONResponse) VisitListLogsResponse(w http.ResponseWriter) error {
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Link", fmt.Sprint(response.Headers.Link))
+	w.Header().Set("X-Next-Cursor", fmt.Sprint(response.Headers.XNextCursor))
 	w.WriteHeader(200)
 
-	return json.NewEncoder(w).Encode(response)
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type ListLogs200ApplicationxNdjsonResponse struct {
+	Body          io.Reader
+	Headers       ListLogs200ResponseHeaders
+	ContentLength int64
+}
+
+func (response ListLogs200ApplicationxNdjsonResponse) VisitListLogsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.Header().Set("Link", fmt.Sprint(response.Headers.Link))
+	w.Header().Set("X-Next-Cursor", fmt.Sprint(response.Headers.XNextCursor))
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
 }
 
 type ListLogs400JSONResponse ErrorResponse
@@ -334,6 +676,24 @@ func (response ListLogs400JSONResponse) VisitListLogsResponse(w http.ResponseWri
 	return json.NewEncoder(w).Encode(response)
 }
 
+type ListLogs401JSONResponse struct{ UnauthorizedJSONResponse }
+
+func (response ListLogs401JSONResponse) VisitListLogsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListLogs403JSONResponse struct{ ForbiddenJSONResponse }
+
+func (response ListLogs403JSONResponse) VisitListLogsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type PostLogRequestObject struct {
 	Body *PostLogJSONRequestBody
 }
@@ -346,14 +706,17 @@ type PostLog202ResponseHeaders struct {
 	Location string
 }
 
-type PostLog202Response struct {
+type PostLog202JSONResponse struct {
+	Body    IngestResult
 	Headers PostLog202ResponseHeaders
 }
 
-func (response PostLog202Response) VisitPostLogResponse(w http.ResponseWriter) error {
+func (response PostLog202JSONResponse) VisitPostLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Location", fmt.Sprint(response.Headers.Location))
 	w.WriteHeader(202)
-	return nil
+
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
 type PostLog400JSONResponse ErrorResponse
@@ -365,6 +728,64 @@ func (response PostLog400JSONResponse) VisitPostLogResponse(w http.ResponseWrite
 	return json.NewEncoder(w).Encode(response)
 }
 
+type PostLog401JSONResponse struct{ UnauthorizedJSONResponse }
+
+func (response PostLog401JSONResponse) VisitPostLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostLog403JSONResponse struct{ ForbiddenJSONResponse }
+
+func (response PostLog403JSONResponse) VisitPostLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostLog413JSONResponse ErrorResponse
+
+func (response PostLog413JSONResponse) VisitPostLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(413)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostLog429ResponseHeaders struct {
+	RetryAfter int
+}
+
+type PostLog429JSONResponse struct {
+	Body    ErrorResponse
+	Headers PostLog429ResponseHeaders
+}
+
+func (response PostLog429JSONResponse) VisitPostLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PostLog503ResponseHeaders struct {
+	RetryAfter int
+}
+
+type PostLog503Response struct {
+	Headers PostLog503ResponseHeaders
+}
+
+func (response PostLog503Response) VisitPostLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(503)
+	return nil
+}
+
 type GetLogsByIdRequestObject struct {
 	Id string `json:"id"`
 }
@@ -391,6 +812,24 @@ func (response GetLogsById400JSONResponse) VisitGetLogsByIdResponse(w http.Respo
 	return json.NewEncoder(w).Encode(response)
 }
 
+type GetLogsById401JSONResponse struct{ UnauthorizedJSONResponse }
+
+func (response GetLogsById401JSONResponse) VisitGetLogsByIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetLogsById403JSONResponse struct{ ForbiddenJSONResponse }
+
+func (response GetLogsById403JSONResponse) VisitGetLogsByIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type GetLogsById404JSONResponse ErrorResponse
 
 func (response GetLogsById404JSONResponse) VisitGetLogsByIdResponse(w http.ResponseWriter) error {
@@ -405,6 +844,9 @@ type StrictServerInterface interface {
 	// Health check endpoint.
 	// (GET /health)
 	Health(ctx context.Context, request HealthRequestObject) (HealthResponseObject, error)
+	// Returns the result of a recent ingest request.
+	// (GET /v1/ingestions/{id})
+	GetIngestion(ctx context.Context, request GetIngestionRequestObject) (GetIngestionResponseObject, error)
 	// An endpoint to retrieve logs.
 	// (GET /v1/logs)
 	ListLogs(ctx context.Context, request ListLogsRequestObject) (ListLogsResponseObject, error)
@@ -469,6 +911,32 @@ func (sh *strictHandler) Health(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetIngestion operation middleware
+func (sh *strictHandler) GetIngestion(w http.ResponseWriter, r *http.Request, id string) {
+	var request GetIngestionRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetIngestion(ctx, request.(GetIngestionRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetIngestion")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetIngestionResponseObject); ok {
+		if err := validResponse.VisitGetIngestionResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // ListLogs operation middleware
 func (sh *strictHandler) ListLogs(w http.ResponseWriter, r *http.Request, params ListLogsParams) {
 	var request ListLogsRequestObject
@@ -1,19 +1,209 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/auth"
+	"github.com/dyptan-io/log-management/v2/internal/platform/certs"
+	"github.com/dyptan-io/log-management/v2/internal/platform/config"
+	"github.com/dyptan-io/log-management/v2/internal/service"
 )
 
+// envPrefix is a prefix of environment variables overriding command-line flags.
+const envPrefix = "RECEIVER_"
+
 // Config contains server configuration for Receiver service.
 type Config struct {
-	HTTPAddr string
+	// File is the path the configuration is loaded from, if any.
+	File string `yaml:"-"`
+	// HTTPAddr is the listener address, it can't be reloaded.
+	HTTPAddr    string        `yaml:"addr"`
+	MaxInFlight int           `yaml:"max_inflight"`
+	RetryAfter  time.Duration `yaml:"retry_after"`
+	// APIKeysFile is a file of hashed API keys, authentication is disabled if it's empty.
+	APIKeysFile string `yaml:"api_keys_file"`
+	// TLS contains the server certificate and the CA bundle client certificates are verified
+	// against, TLS is disabled if the certificate is not given. Changes of the files are
+	// picked up without a restart, but changes of the paths require one.
+	TLS certs.Files `yaml:"tls"`
+	// TenantDefaults are retention and quota settings of tenants not listed in Tenants.
+	TenantDefaults service.TenantSettings            `yaml:"tenant_defaults"`
+	Tenants        map[string]service.TenantSettings `yaml:"tenants"`
+	// IngestionTTL is how long results of ingest requests are available at their Location.
+	IngestionTTL time.Duration `yaml:"ingestion_ttl"`
+	// Ingest limits the size of ingested requests and their entries.
+	Ingest service.IngestLimits `yaml:"ingest"`
+	// RateLimits are ingestion rate limits and daily quotas of every API key or IP address.
+	RateLimits service.RateLimits `yaml:"rate_limits"`
+	// Roles are policies restricting entries readable with API keys having the role.
+	Roles map[string]service.Policy `yaml:"roles"`
+	// IndexedAttributes are attributes entries are indexed by in addition to severity,
+	// changes of them require a restart.
+	IndexedAttributes []string `yaml:"indexed_attributes"`
 }
 
-func readConfig() Config {
-	config := Config{}
+// readConfig reads command-line flags and environment variables. Settings of the
+// configuration file given with the -config flag take precedence over them.
+func readConfig() (Config, error) {
+	var configPath string
+
+	cfg := Config{IndexedAttributes: service.DefaultIndexedAttributes}
 
-	flag.StringVar(&config.HTTPAddr, "addr", ":8080", "an address for HTTP server listener")
+	flag.StringVar(&configPath, "config", "", "a YAML or JSON configuration file, reloaded on SIGHUP")
+	flag.StringVar(&cfg.HTTPAddr, "addr", ":8080", "an address for HTTP server listener")
+	flag.IntVar(&cfg.MaxInFlight, "max-inflight", 0, "max concurrent ingest requests before signaling overload, 0 disables the limit")
+	flag.DurationVar(&cfg.RetryAfter, "retry-after", time.Second, "a delay clients are asked to wait when the server is overloaded")
+	flag.StringVar(&cfg.TLS.CertFile, "tls-cert-file", "", "a PEM encoded TLS certificate file, TLS is disabled if empty")
+	flag.StringVar(&cfg.TLS.KeyFile, "tls-key-file", "", "a PEM encoded private key file of the TLS certificate")
+	flag.StringVar(&cfg.TLS.CAFile, "tls-client-ca-file", "", "a PEM encoded CA bundle to verify client certificates against, enables mutual TLS")
+	flag.DurationVar(&cfg.TenantDefaults.Retention, "retention", 0, "how long entries are kept after their timestamp, 0 keeps them forever")
+	flag.IntVar(&cfg.TenantDefaults.MaxEntries, "max-entries", 0, "max number of stored entries per tenant, 0 disables the limit")
+	flag.Int64Var(&cfg.TenantDefaults.MaxBytes, "max-bytes", 0, "max approximate size of stored entries per tenant, 0 disables the limit")
+	flag.DurationVar(&cfg.IngestionTTL, "ingestion-ttl", time.Hour, "how long results of ingest requests are kept")
+	flag.Int64Var(&cfg.Ingest.MaxBodyBytes, "max-body-bytes", 10<<20, "max size of ingest request bodies, 0 disables the limit")
+	flag.IntVar(&cfg.Ingest.MaxBatchEntries, "max-batch-entries", 10000, "max entries per ingest request, 0 disables the limit")
+	flag.IntVar(&cfg.Ingest.MaxAttributes, "max-attributes", 512, "max attributes per entry including nested ones, 0 disables the limit")
+	flag.IntVar(&cfg.Ingest.MaxAttributeDepth, "max-attribute-depth", 10, "max nesting depth of entry attributes, 0 disables the limit")
+	flag.Float64Var(&cfg.RateLimits.RequestsPerSecond, "rate-limit-requests", 0, "max ingest requests per second per client, 0 disables the limit")
+	flag.Float64Var(&cfg.RateLimits.EntriesPerSecond, "rate-limit-entries", 0, "max ingested entries per second per client, 0 disables the limit")
+	flag.Int64Var(&cfg.RateLimits.DailyBytes, "daily-bytes-quota", 0, "max ingested bytes per client per day, 0 disables the quota")
+	flag.StringVar((*string)(&cfg.TenantDefaults.Duplicates), "duplicates", string(service.FirstWriteWins),
+		"a policy of entries with IDs that are already stored: first-write-wins, last-write-wins or conflict-error")
+	flag.StringVar(&cfg.APIKeysFile, "api-keys-file", "", "a YAML or JSON file of hashed API keys with scopes, authentication is disabled if empty")
+	flag.Func("indexed-attributes", "comma-separated attributes entries are indexed by (default "+
+		strings.Join(service.DefaultIndexedAttributes, ",")+")", func(s string) error {
+		cfg.IndexedAttributes = slices.DeleteFunc(strings.Split(s, ","), func(name string) bool { return name == "" })
+		return nil
+	})
 	flag.Parse()
 
-	return config
+	if err := config.ApplyEnv(flag.CommandLine, envPrefix); err != nil {
+		return Config{}, err
+	}
+
+	if configPath != "" {
+		return loadConfig(configPath, cfg)
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// loadConfig reads the configuration file on top of the defaults and validates it. Tenants and
+// roles are taken from the file only: they are decoded into new maps rather than merged into ones
+// of the defaults, which may be in use, so that ones removed from the file are removed on reload.
+func loadConfig(path string, defaults Config) (Config, error) {
+	cfg := defaults
+	cfg.File = path
+	cfg.Tenants, cfg.Roles = nil, nil
+
+	if err := config.Load(path, &cfg); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// keys loads API keys if authentication is enabled.
+func (c Config) keys() (*auth.Keys, error) {
+	if c.APIKeysFile == "" {
+		return nil, nil
+	}
+
+	return auth.LoadKeys(c.APIKeysFile)
+}
+
+// Validate checks the whole configuration and reports all invalid keys.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.HTTPAddr == "" {
+		errs = append(errs, errors.New("addr: must not be empty"))
+	}
+
+	if c.MaxInFlight < 0 {
+		errs = append(errs, fmt.Errorf("max_inflight: must not be negative, got %d", c.MaxInFlight))
+	}
+
+	if c.RetryAfter < 0 {
+		errs = append(errs, fmt.Errorf("retry_after: must not be negative, got %s", c.RetryAfter))
+	}
+
+	errs = append(errs, validateTenant("tenant_defaults", c.TenantDefaults))
+
+	for name, settings := range c.Tenants {
+		errs = append(errs, validateTenant(fmt.Sprintf("tenants.%s", name), settings))
+	}
+
+	if c.IngestionTTL <= 0 {
+		errs = append(errs, fmt.Errorf("ingestion_ttl: must be positive, got %s", c.IngestionTTL))
+	}
+
+	if i := c.Ingest; i.MaxBodyBytes < 0 || i.MaxBatchEntries < 0 || i.MaxAttributes < 0 || i.MaxAttributeDepth < 0 {
+		errs = append(errs, errors.New("ingest: limits must not be negative"))
+	}
+
+	if r := c.RateLimits; r.RequestsPerSecond < 0 || r.RequestsBurst < 0 || r.EntriesPerSecond < 0 || r.EntriesBurst < 0 || r.DailyBytes < 0 {
+		errs = append(errs, errors.New("rate_limits: limits must not be negative"))
+	}
+
+	for i, name := range c.IndexedAttributes {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("indexed_attributes[%d]: must not be empty", i))
+		}
+	}
+
+	for name, policy := range c.Roles {
+		errs = append(errs, validatePolicy(fmt.Sprintf("roles.%s", name), policy))
+	}
+
+	if err := c.TLS.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("tls: %w", err))
+	} else if c.TLS.CAFile != "" && c.TLS.CertFile == "" {
+		errs = append(errs, errors.New("tls.ca_file: requires the server certificate"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateTenant(key string, s service.TenantSettings) error {
+	var errs []error
+
+	if s.Retention < 0 || s.MaxEntries < 0 || s.MaxBytes < 0 {
+		errs = append(errs, fmt.Errorf("%s: retention and quotas must not be negative", key))
+	}
+
+	if err := s.Duplicates.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("%s.duplicates: %w", key, err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validatePolicy(key string, p service.Policy) error {
+	var errs []error
+
+	for i, severity := range p.Severities {
+		if severity == "" {
+			errs = append(errs, fmt.Errorf("%s.severities[%d]: must not be empty", key, i))
+		}
+	}
+
+	for name := range p.Match {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("%s.match: attribute names must not be empty", key))
+		}
+	}
+
+	for i, name := range p.Mask {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("%s.mask[%d]: must not be empty", key, i))
+		}
+	}
+
+	return errors.Join(errs...)
 }
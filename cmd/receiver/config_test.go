@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/internal/service"
+)
+
+func TestLoadConfig_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receiver.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+ingestion_ttl: 1h
+tenants:
+  payments: {max_entries: 10}
+  billing: {max_entries: 20}
+roles:
+  oncall: {severities: [Error], mask: [user.ssn]}
+  auditor: {severities: [Info]}
+`), 0o600))
+
+	current, err := loadConfig(path, Config{HTTPAddr: ":8080", IngestionTTL: time.Minute})
+	require.NoError(t, err)
+
+	tenants, roles := current.Tenants, current.Roles
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+ingestion_ttl: 2h
+tenants:
+  payments: {max_entries: 30}
+roles:
+  oncall: {severities: [Error, Warning]}
+`), 0o600))
+
+	reloaded, err := loadConfig(path, current)
+	require.NoError(t, err)
+
+	// Tenants and roles removed from the file are removed, and the current ones aren't modified.
+	require.Equal(t, map[string]service.TenantSettings{"payments": {MaxEntries: 30}}, reloaded.Tenants)
+	require.Equal(t, map[string]service.Policy{"oncall": {Severities: []string{"Error", "Warning"}}}, reloaded.Roles)
+	require.Equal(t, ":8080", reloaded.HTTPAddr)
+	require.Equal(t, 2*time.Hour, reloaded.IngestionTTL)
+
+	require.Len(t, tenants, 2)
+	require.Equal(t, service.Policy{Severities: []string{"Error"}, Mask: []string{"user.ssn"}}, roles["oncall"])
+}
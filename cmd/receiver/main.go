@@ -5,30 +5,178 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/async"
+	"github.com/dyptan-io/log-management/v2/internal/platform/auth"
+	"github.com/dyptan-io/log-management/v2/internal/platform/certs"
+	"github.com/dyptan-io/log-management/v2/internal/platform/config"
 	"github.com/dyptan-io/log-management/v2/internal/platform/server"
 	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
 	"github.com/dyptan-io/log-management/v2/internal/service"
 )
 
+const (
+	// retentionInterval is an interval of deleting expired log entries.
+	retentionInterval = time.Minute
+	// pruneInterval is an interval of forgetting rate limits of idle clients.
+	pruneInterval = time.Minute
+)
+
 func main() {
-	config := readConfig()
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
-	store := storage.NewInMemory[service.LogEntry]()
-	repository := service.NewRepository(store)
+	cfg, err := readConfig()
+	if err != nil {
+		logger.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	keys, err := cfg.keys()
+	if err != nil {
+		logger.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	store := storage.NewNamespaced[service.LogEntry]()
+	service.IndexLogs(store, cfg.IndexedAttributes)
+
+	metrics := service.NewMetrics(store)
+	tenants := service.NewTenants(store, cfg.TenantDefaults, cfg.Tenants).WithMetrics(metrics)
+	policies := service.NewPolicies(cfg.Roles)
+	limits := service.NewClientLimits(cfg.RateLimits)
+	validator := service.NewValidator(cfg.Ingest)
+	ingestions := service.NewIngestions(cfg.IngestionTTL)
 	router := http.NewServeMux()
 
-	api.HandlerFromMux(service.NewServer(repository, logger), router)
+	srv := service.NewServer(tenants, logger).
+		WithPolicies(policies).
+		WithLimits(limits).
+		WithValidator(validator).
+		WithIngestions(ingestions)
+	api.HandlerFromMux(service.Instrument(srv, metrics), router)
+
+	limiter := server.NewInFlightLimiter(cfg.MaxInFlight, cfg.RetryAfter)
+	authenticator := auth.NewAuthenticator(keys)
+	reload := reloader(cfg, limiter, authenticator, tenants, policies, limits, validator, ingestions, logger)
 
-	srv := server.New(&http.Server{
-		Addr:    config.HTTPAddr,
-		Handler: router,
+	// Endpoints require the read scope unless they are explicitly registered with another one.
+	handler := http.NewServeMux()
+	handler.Handle("/", authenticator.Require(auth.ScopeRead)(router))
+	handler.Handle("GET /health", router)
+	// Metrics have series of every tenant, so they are exposed to administrators only.
+	handler.Handle("GET /metrics", authenticator.Require(auth.ScopeAdmin)(metrics))
+	handler.Handle("POST /v1/logs", authenticator.Require(auth.ScopeIngest)(limiter.Middleware(router)))
+	handler.Handle("GET /v1/ingestions/{id}", authenticator.Require(auth.ScopeIngest)(router))
+	handler.Handle("GET /admin/limits", authenticator.Require(auth.ScopeAdmin)(limits))
+	handler.Handle("POST /admin/reload", authenticator.Require(auth.ScopeAdmin)(config.ReloadHandler(reload, logger)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	config.ReloadOnSignal(ctx, reload, logger)
+
+	async.Schedule(ctx, retentionInterval, func(context.Context) error {
+		if n := tenants.Expire(time.Now()); n > 0 {
+			logger.Info("expired log entries deleted", "entries", n)
+		}
+
+		ingestions.Expire(time.Now())
+
+		return nil
 	}, logger)
 
-	if err := srv.Serve(context.Background()); err != nil {
+	async.Schedule(ctx, pruneInterval, func(context.Context) error {
+		limits.Prune()
+
+		return nil
+	}, logger)
+
+	httpSrv := &http.Server{
+		Addr:    cfg.HTTPAddr,
+		Handler: handler,
+	}
+
+	var listener server.Listener = httpSrv
+
+	if cfg.TLS.CertFile != "" {
+		// Rotated certificates are reloaded on the next TLS handshake.
+		certificates, err := certs.NewReloader(cfg.TLS)
+		if err != nil {
+			logger.Error("invalid configuration", "error", err)
+			os.Exit(1)
+		}
+
+		httpSrv.TLSConfig = certificates.ServerConfig()
+		listener = server.NewTLSServer(httpSrv)
+	}
+
+	err = server.New(listener, logger).Serve(ctx)
+
+	cancel()
+
+	if err != nil {
 		logger.Error("fatal error occurred", "error", err)
 		os.Exit(1)
 	}
 }
+
+// reloader returns a function that reads the configuration file again and applies the new
+// overload settings, API keys, tenant settings, role policies, rate and ingest limits, and the TTL of
+// ingest results. The listener address and TLS files require a restart to change.
+func reloader(
+	current Config,
+	limiter *server.InFlightLimiter,
+	authenticator *auth.Authenticator,
+	tenants *service.Tenants,
+	policies *service.Policies,
+	limits *service.ClientLimits,
+	validator *service.Validator,
+	ingestions *service.Ingestions,
+	logger *slog.Logger,
+) config.ReloadFunc {
+	var mu sync.Mutex
+
+	return func() error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if current.File == "" {
+			return config.ErrNoConfigFile
+		}
+
+		// Settings omitted in the file keep their current values, except for tenants and roles.
+		// Everything is validated before any setting is updated.
+		cfg, err := loadConfig(current.File, current)
+		if err != nil {
+			return err
+		}
+
+		keys, err := cfg.keys()
+		if err != nil {
+			return err
+		}
+
+		if cfg.HTTPAddr != current.HTTPAddr {
+			logger.Warn("listener address change requires a restart", "addr", current.HTTPAddr, "new_addr", cfg.HTTPAddr)
+			cfg.HTTPAddr = current.HTTPAddr
+		}
+
+		if cfg.TLS != current.TLS {
+			logger.Warn("TLS files change requires a restart", "tls", current.TLS, "new_tls", cfg.TLS)
+			cfg.TLS = current.TLS
+		}
+
+		limiter.Update(cfg.MaxInFlight, cfg.RetryAfter)
+		authenticator.Update(keys)
+		tenants.Update(cfg.TenantDefaults, cfg.Tenants)
+		policies.Update(cfg.Roles)
+		limits.Update(cfg.RateLimits)
+		validator.Update(cfg.Ingest)
+		ingestions.Update(cfg.IngestionTTL)
+		current = cfg
+
+		return nil
+	}
+}
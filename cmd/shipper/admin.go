@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/config"
+	"github.com/dyptan-io/log-management/v2/internal/platform/fs"
+)
+
+// readyTimeout limits the time of checking receivers reachability.
+const readyTimeout = 3 * time.Second
+
+type (
+	// sourceStatus is a response body of the /status endpoint item.
+	sourceStatus struct {
+		Name  string       `json:"name"`
+		Files []fileStatus `json:"files"`
+	}
+
+	fileStatus struct {
+		Path   string `json:"path"`
+		Offset int64  `json:"offset"`
+		Size   int64  `json:"size"`
+		// Lag is the number of bytes not read yet.
+		Lag      int64      `json:"lag"`
+		LastRead *time.Time `json:"last_read,omitempty"`
+	}
+)
+
+func newFileStatus(f fs.FileStatus) fileStatus {
+	status := fileStatus{
+		Path:   f.Path,
+		Offset: f.Offset,
+		Size:   f.Size,
+		Lag:    max(f.Size-f.Offset, 0),
+	}
+
+	if !f.LastRead.IsZero() {
+		status.LastRead = &f.LastRead
+	}
+
+	return status
+}
+
+// newAdminHandler returns a handler of the admin endpoints: liveness and readiness probes,
+// file reading status, Prometheus metrics and configuration reload.
+func newAdminHandler(sources *supervisor, m *pipelineMetrics, logger *slog.Logger) http.Handler {
+	router := http.NewServeMux()
+
+	router.HandleFunc("GET /health", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	router.HandleFunc("GET /ready", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		if err := sources.Ready(ctx); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	})
+
+	router.HandleFunc("GET /status", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string][]sourceStatus{"sources": sources.Status()})
+	})
+
+	router.Handle("GET /metrics", m.registry)
+	router.Handle("POST /admin/reload", config.ReloadHandler(sources.Reload, logger))
+
+	return router
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
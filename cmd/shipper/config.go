@@ -1,26 +1,618 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/balancer"
+	"github.com/dyptan-io/log-management/v2/internal/platform/certs"
+	"github.com/dyptan-io/log-management/v2/internal/platform/config"
+	"github.com/dyptan-io/log-management/v2/internal/processor"
 )
 
-// Config is a struct that contains Shipper service configuration.
-type Config struct {
-	ReceiverAddr string
-	WatchDirs    []string
-}
+// envPrefix is a prefix of environment variables overriding command-line flags.
+const envPrefix = "SHIPPER_"
+
+type (
+	// Config is a struct that contains Shipper service configuration.
+	Config struct {
+		// File is the path the configuration is loaded from, if any.
+		File string `yaml:"-"`
+		// Admin contains settings of the admin HTTP listener, they can't be reloaded.
+		Admin AdminConfig `yaml:"admin"`
+		// Sources are log sources, each with its own processing pipeline.
+		Sources []SourceConfig `yaml:"sources"`
+		// Outputs are used by sources that don't have their own outputs.
+		Outputs []OutputConfig `yaml:"outputs"`
+		Balance BalanceConfig  `yaml:"balance"`
+	}
+
+	// SourceConfig contains configuration of a log source and its pipeline.
+	SourceConfig struct {
+		Name      string           `yaml:"name"`
+		WatchDirs []string         `yaml:"watch_dirs"`
+		Decoder   DecoderConfig    `yaml:"decoder"`
+		Multiline *MultilineConfig `yaml:"multiline"`
+		// Transforms are applied in the given order.
+		Transforms []TransformConfig `yaml:"transforms"`
+		Outputs    []OutputConfig    `yaml:"outputs"`
+	}
+
+	// DecoderConfig contains configuration of log lines decoding.
+	DecoderConfig struct {
+		// Type is either json or text.
+		Type string `yaml:"type"`
+		// Severity is assigned to entries decoded by the text decoder.
+		Severity string `yaml:"severity"`
+	}
+
+	// MultilineConfig contains rules of joining lines into a single message.
+	MultilineConfig struct {
+		// Pattern matches continuation lines, or first lines if Start is set.
+		Pattern  string        `yaml:"pattern"`
+		Start    bool          `yaml:"start"`
+		MaxLines int           `yaml:"max_lines"`
+		Timeout  time.Duration `yaml:"timeout"`
+	}
+
+	// TransformConfig contains configuration of a single pipeline stage,
+	// exactly one of its fields must be set.
+	TransformConfig struct {
+		Redact    *RedactConfig    `yaml:"redact"`
+		Sample    *SampleConfig    `yaml:"sample"`
+		RateLimit *RateLimitConfig `yaml:"rate_limit"`
+	}
+
+	// RedactConfig contains configuration of the PII redaction stage.
+	RedactConfig struct {
+		Detectors []string          `yaml:"detectors"`
+		Patterns  map[string]string `yaml:"patterns"`
+		Mode      string            `yaml:"mode"`
+		KeyFile   string            `yaml:"key_file"`
+	}
+
+	// SampleConfig contains configuration of the sampling stage.
+	SampleConfig struct {
+		Mode        string             `yaml:"mode"`
+		DefaultRate *float64           `yaml:"default_rate"`
+		Rules       map[string]float64 `yaml:"rules"`
+	}
+
+	// RateLimitConfig contains configuration of the rate limiting stage.
+	RateLimitConfig struct {
+		Rate    float64 `yaml:"rate"`
+		Burst   int     `yaml:"burst"`
+		KeyAttr string  `yaml:"key"`
+	}
+
+	// OutputConfig contains configuration of a single output and its route.
+	OutputConfig struct {
+		// URLs has more than one address if requests are balanced across several receivers.
+		URLs          []string      `yaml:"urls"`
+		Match         []string      `yaml:"match"`
+		Final         bool          `yaml:"final"`
+		BatchSize     int           `yaml:"batch_size"`
+		FlushInterval time.Duration `yaml:"flush_interval"`
+		MaxRetries    *int          `yaml:"max_retries"`
+		// APIKeyFile is a file with the API key of receivers.
+		APIKeyFile string `yaml:"api_key_file"`
+		// Tenant is the receiver tenant entries are sent to, unless the API key is bound to one.
+		Tenant string `yaml:"tenant"`
+		// TLS contains the CA bundle receivers are verified against instead of the system roots,
+		// and the client certificate for receivers requiring mutual TLS.
+		TLS certs.Files `yaml:"tls"`
+	}
+
+	// AdminConfig contains configuration of the admin HTTP listener.
+	AdminConfig struct {
+		// Addr is the listener address, the listener is disabled if it's empty.
+		Addr string `yaml:"addr"`
+	}
+
+	// BalanceConfig contains configuration of balancing across several receivers.
+	BalanceConfig struct {
+		Strategy         string        `yaml:"strategy"`
+		HealthInterval   time.Duration `yaml:"health_interval"`
+		FailureThreshold int           `yaml:"failure_threshold"`
+	}
+)
+
+// readConfig reads the configuration file if it's given with the -config flag.
+// Otherwise, a single source is configured with command-line flags.
+func readConfig() (Config, error) {
+	var (
+		configPath, watchDirs, receiverAddr, apiKeyFile, tenant, detectors string
+
+		tls certs.Files
 
-func readConfig() Config {
-	var config Config
+		source    SourceConfig
+		redact    RedactConfig
+		sample    SampleConfig
+		rateLimit RateLimitConfig
+		outputs   []OutputConfig
+		balance   BalanceConfig
+		admin     AdminConfig
+	)
 
-	var watchDirs string
+	redact.Patterns = make(map[string]string)
 
+	flag.StringVar(&configPath, "config", "", "a YAML or JSON configuration file, reloaded on SIGHUP; other flags except -admin-addr are ignored if it's given")
+	flag.StringVar(&admin.Addr, "admin-addr", "", "an address of the admin HTTP listener, disabled if empty")
 	flag.StringVar(&watchDirs, "watch-dirs", "./testdata", "directories to watch for log files")
-	flag.StringVar(&config.ReceiverAddr, "receiver-addr", "http://localhost:8080", "an address of the receiver server")
+	flag.StringVar(&receiverAddr, "receiver-addr", "http://localhost:8080", "comma-separated addresses of the receiver servers")
+	flag.StringVar(&apiKeyFile, "api-key-file", "", "a file with the API key to authenticate to the receiver servers")
+	flag.StringVar(&tenant, "tenant", "", "a receiver tenant to send entries to, unless the API key is bound to one")
+	flag.StringVar(&tls.CAFile, "tls-ca-file", "", "a PEM encoded CA bundle to verify receiver certificates against instead of system roots")
+	flag.StringVar(&tls.CertFile, "tls-cert-file", "", "a PEM encoded client certificate file for receivers requiring mutual TLS")
+	flag.StringVar(&tls.KeyFile, "tls-key-file", "", "a PEM encoded private key file of the client certificate")
+	flag.StringVar(&source.Decoder.Type, "decoder", "json", "how to decode log lines (json or text)")
+	flag.StringVar(&detectors, "redact", "", "built-in PII detectors to apply (email,ipv4,ipv6,card,bearer,jwt)")
+	flag.Func("redact-pattern", "a custom PII detector in name=regexp form (repeatable)", func(s string) error {
+		name, expr, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("expected name=regexp, got %q", s)
+		}
+
+		redact.Patterns[name] = expr
+
+		return nil
+	})
+	flag.StringVar(&redact.Mode, "redact-mode", "mask", "how to redact detected values (mask, hash or drop)")
+	flag.StringVar(&redact.KeyFile, "redact-key-file", "", "a file with the HMAC key for the hash redaction mode")
+	flag.Func("sample", "sampling rates per severity, e.g. error=1,debug=0.1,*=0.5", func(s string) error {
+		rules, defaultRate, err := parseSampleRules(s)
+		if err != nil {
+			return err
+		}
+
+		sample.Rules, sample.DefaultRate = rules, &defaultRate
+
+		return nil
+	})
+	flag.StringVar(&sample.Mode, "sample-mode", "deterministic", "how to sample entries (deterministic by ID hash or random)")
+	flag.Float64Var(&rateLimit.Rate, "rate-limit", 0, "max entries per second to send, 0 disables the limit")
+	flag.IntVar(&rateLimit.Burst, "rate-limit-burst", 100, "max burst of entries above the rate limit")
+	flag.StringVar(&rateLimit.KeyAttr, "rate-limit-key", "", "an attribute identifying the source to limit separately")
+	flag.Func("output", "an additional output URL (http(s)://, file:// or stdout:) with optional "+
+		"match=field:value, final, batch-size, flush-interval, max-retries, api-key-file and tenant query parameters (repeatable)", func(s string) error {
+		output, err := parseOutput(s)
+		if err != nil {
+			return err
+		}
+
+		outputs = append(outputs, output)
+
+		return nil
+	})
+	flag.StringVar(&balance.Strategy, "balance", string(balancer.RoundRobin), "how to balance requests across receivers (round-robin or least-pending)")
+	flag.DurationVar(&balance.HealthInterval, "health-interval", 5*time.Second, "an interval of receivers health checks")
+	flag.IntVar(&balance.FailureThreshold, "failure-threshold", 3, "consecutive failures to eject a receiver")
 	flag.Parse()
 
-	config.WatchDirs = strings.Split(watchDirs, ",")
+	if err := config.ApplyEnv(flag.CommandLine, envPrefix); err != nil {
+		return Config{}, err
+	}
+
+	if configPath != "" {
+		cfg, err := loadConfig(configPath)
+		if cfg.Admin.Addr == "" {
+			cfg.Admin = admin
+		}
+
+		return cfg, err
+	}
+
+	// Sampling and rate limiting go first to avoid redacting entries that are dropped anyway.
+	if len(sample.Rules) > 0 || sample.DefaultRate != nil {
+		source.Transforms = append(source.Transforms, TransformConfig{Sample: &sample})
+	}
+
+	if rateLimit.Rate > 0 {
+		source.Transforms = append(source.Transforms, TransformConfig{RateLimit: &rateLimit})
+	}
+
+	if detectors != "" {
+		redact.Detectors = strings.Split(detectors, ",")
+	}
+
+	if len(redact.Detectors) > 0 || len(redact.Patterns) > 0 {
+		source.Transforms = append(source.Transforms, TransformConfig{Redact: &redact})
+	}
+
+	// The receiver is the catch-all route for entries not consumed by final outputs.
+	if receiverAddr != "" {
+		outputs = append(outputs, OutputConfig{
+			URLs:       strings.Split(receiverAddr, ","),
+			APIKeyFile: apiKeyFile,
+			Tenant:     tenant,
+			TLS:        tls,
+		})
+	}
+
+	source.Name = "default"
+	source.WatchDirs = strings.Split(watchDirs, ",")
+
+	cfg := Config{
+		Admin:   admin,
+		Sources: []SourceConfig{source},
+		Outputs: outputs,
+		Balance: balance,
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// loadConfig reads and validates the configuration file.
+func loadConfig(path string) (Config, error) {
+	cfg := Config{
+		File: path,
+		Balance: BalanceConfig{
+			Strategy:         string(balancer.RoundRobin),
+			HealthInterval:   5 * time.Second,
+			FailureThreshold: 3,
+		},
+	}
+
+	if err := config.Load(path, &cfg); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// Validate checks the whole configuration and reports all invalid keys.
+func (c Config) Validate() error {
+	var errs []error
+
+	if len(c.Sources) == 0 {
+		errs = append(errs, errors.New("sources: at least one source is required"))
+	}
+
+	names := make(map[string]bool)
+
+	for i, s := range c.Sources {
+		key := fmt.Sprintf("sources[%d]", i)
+
+		if s.Name == "" {
+			errs = append(errs, fmt.Errorf("%s.name: must not be empty", key))
+		} else if names[s.Name] {
+			errs = append(errs, fmt.Errorf("%s.name: duplicate source %q", key, s.Name))
+		}
+
+		names[s.Name] = true
+
+		errs = append(errs, s.validate(key))
+
+		if len(s.Outputs) == 0 && len(c.Outputs) == 0 {
+			errs = append(errs, fmt.Errorf("%s.outputs: at least one output is required", key))
+		}
+	}
+
+	for i, o := range c.Outputs {
+		errs = append(errs, o.validate(fmt.Sprintf("outputs[%d]", i)))
+	}
+
+	if _, err := c.Balance.options(); err != nil {
+		errs = append(errs, fmt.Errorf("balance.strategy: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s SourceConfig) validate(key string) error {
+	var errs []error
+
+	if len(s.WatchDirs) == 0 {
+		errs = append(errs, fmt.Errorf("%s.watch_dirs: at least one directory is required", key))
+	}
+
+	if _, err := s.Decoder.decoder(); err != nil {
+		errs = append(errs, fmt.Errorf("%s.decoder.type: %w", key, err))
+	}
+
+	if s.Multiline != nil {
+		if _, err := s.Multiline.options(); err != nil {
+			errs = append(errs, fmt.Errorf("%s.multiline.pattern: %w", key, err))
+		}
+	}
+
+	for i, t := range s.Transforms {
+		errs = append(errs, t.validate(fmt.Sprintf("%s.transforms[%d]", key, i)))
+	}
+
+	for i, o := range s.Outputs {
+		errs = append(errs, o.validate(fmt.Sprintf("%s.outputs[%d]", key, i)))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (t TransformConfig) validate(key string) error {
+	var set int
+
+	for _, isSet := range []bool{t.Redact != nil, t.Sample != nil, t.RateLimit != nil} {
+		if isSet {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return fmt.Errorf("%s: exactly one of redact, sample or rate_limit is required", key)
+	}
+
+	switch {
+	case t.Redact != nil:
+		if _, err := t.Redact.detectors(); err != nil {
+			return fmt.Errorf("%s.redact.detectors: %w", key, err)
+		}
+
+		// The key file is read only when the pipeline is built, so its name stands in for the key.
+		if _, err := processor.NewRedactor(t.Redact.mode(), []byte(t.Redact.KeyFile)); err != nil {
+			return fmt.Errorf("%s.redact: %w", key, err)
+		}
+	case t.Sample != nil:
+		if _, err := t.Sample.sampler(); err != nil {
+			return fmt.Errorf("%s.sample: %w", key, err)
+		}
+	case t.RateLimit != nil:
+		if t.RateLimit.Rate <= 0 || t.RateLimit.Burst <= 0 {
+			return fmt.Errorf("%s.rate_limit: rate and burst must be positive", key)
+		}
+	}
+
+	return nil
+}
+
+func (o OutputConfig) validate(key string) error {
+	var errs []error
+
+	if len(o.URLs) == 0 {
+		errs = append(errs, fmt.Errorf("%s.urls: at least one URL is required", key))
+	}
+
+	for i, rawURL := range o.URLs {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s.urls[%d]: %w", key, i, err))
+			continue
+		}
+
+		switch u.Scheme {
+		case "https":
+		case "http":
+			if o.TLS != (certs.Files{}) {
+				errs = append(errs, fmt.Errorf("%s.urls[%d]: TLS settings require https URL", key, i))
+			}
+		case "file", "stdout":
+			if len(o.URLs) > 1 {
+				errs = append(errs, fmt.Errorf("%s.urls[%d]: only receiver outputs support several URLs", key, i))
+			}
+
+			if o.APIKeyFile != "" {
+				errs = append(errs, fmt.Errorf("%s.api_key_file: only receiver outputs support API keys", key))
+			}
+
+			if o.Tenant != "" {
+				errs = append(errs, fmt.Errorf("%s.tenant: only receiver outputs support tenants", key))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s.urls[%d]: unsupported output %q", key, i, rawURL))
+		}
+	}
+
+	if err := o.TLS.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("%s.tls: %w", key, err))
+	}
+
+	for i, m := range o.Match {
+		if _, err := processor.ParseCondition(m); err != nil {
+			errs = append(errs, fmt.Errorf("%s.match[%d]: %w", key, i, err))
+		}
+	}
+
+	if o.BatchSize < 0 {
+		errs = append(errs, fmt.Errorf("%s.batch_size: must not be negative", key))
+	}
+
+	return errors.Join(errs...)
+}
+
+// apiKey reads the API key of receivers, it's empty if authentication is not configured.
+func (o OutputConfig) apiKey() (string, error) {
+	if o.APIKeyFile == "" {
+		return "", nil
+	}
+
+	b, err := os.ReadFile(o.APIKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("reading API key: %w", err)
+	}
+
+	return string(bytes.TrimSpace(b)), nil
+}
+
+// parseSampleRules parses comma-separated severity=rate pairs, where the "*" severity
+// sets the default rate.
+func parseSampleRules(s string) (map[string]float64, float64, error) {
+	rules := make(map[string]float64)
+	defaultRate := 1.0
+
+	for _, rule := range strings.Split(s, ",") {
+		severity, rawRate, ok := strings.Cut(rule, "=")
+		if !ok || severity == "" {
+			return nil, 0, fmt.Errorf("expected severity=rate, got %q", rule)
+		}
+
+		rate, err := strconv.ParseFloat(rawRate, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("parsing %q rate: %w", severity, err)
+		}
+
+		if severity == "*" {
+			defaultRate = rate
+		} else {
+			rules[severity] = rate
+		}
+	}
+
+	return rules, defaultRate, nil
+}
+
+// parseOutput parses the output URL, where routing and batching settings are passed
+// as query parameters, e.g. http://security:8080?match=attributes.tags:security&final.
+// Several comma-separated receiver URLs may share the same settings.
+func parseOutput(s string) (OutputConfig, error) {
+	addrs, rawQuery, _ := strings.Cut(s, "?")
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return OutputConfig{}, fmt.Errorf("parsing output settings: %w", err)
+	}
+
+	config := OutputConfig{
+		URLs:       strings.Split(addrs, ","),
+		Match:      query["match"],
+		Final:      query.Has("final") && query.Get("final") != "false",
+		APIKeyFile: query.Get("api-key-file"),
+		Tenant:     query.Get("tenant"),
+	}
+
+	if v := query.Get("batch-size"); v != "" {
+		if config.BatchSize, err = strconv.Atoi(v); err != nil {
+			return OutputConfig{}, fmt.Errorf("parsing batch-size of %q: %w", addrs, err)
+		}
+	}
+
+	if v := query.Get("flush-interval"); v != "" {
+		if config.FlushInterval, err = time.ParseDuration(v); err != nil {
+			return OutputConfig{}, fmt.Errorf("parsing flush-interval of %q: %w", addrs, err)
+		}
+	}
+
+	if v := query.Get("max-retries"); v != "" {
+		maxRetries, err := strconv.Atoi(v)
+		if err != nil {
+			return OutputConfig{}, fmt.Errorf("parsing max-retries of %q: %w", addrs, err)
+		}
+
+		config.MaxRetries = &maxRetries
+	}
+
+	return config, nil
+}
+
+func (d DecoderConfig) decoder() (processor.SourceDecoder, error) {
+	switch d.Type {
+	case "", "json":
+		return processor.DecoderJSON{}, nil
+	case "text":
+		severity := d.Severity
+		if severity == "" {
+			severity = "Information"
+		}
+
+		return processor.DecoderText{Severity: severity}, nil
+	default:
+		return nil, fmt.Errorf("unknown decoder %q", d.Type)
+	}
+}
+
+func (m MultilineConfig) options() (processor.MultilineOptions, error) {
+	pattern, err := regexp.Compile(m.Pattern)
+	if err != nil {
+		return processor.MultilineOptions{}, err
+	}
+
+	return processor.MultilineOptions{
+		Pattern:  pattern,
+		Start:    m.Start,
+		MaxLines: m.MaxLines,
+		Timeout:  m.Timeout,
+	}, nil
+}
+
+// detectors returns the configured detectors, or all built-in ones if none is configured.
+func (c RedactConfig) detectors() ([]processor.Detector, error) {
+	var detectors []processor.Detector
+
+	if len(c.Detectors) > 0 || len(c.Patterns) == 0 {
+		builtin, err := processor.Detectors(c.Detectors...)
+		if err != nil {
+			return nil, err
+		}
+
+		detectors = append(detectors, builtin...)
+	}
+
+	for name, expr := range c.Patterns {
+		d, err := processor.NewDetector(name, expr)
+		if err != nil {
+			return nil, err
+		}
+
+		detectors = append(detectors, d)
+	}
+
+	return detectors, nil
+}
+
+func (c RedactConfig) mode() processor.RedactMode {
+	if c.Mode == "" {
+		return processor.RedactMask
+	}
+
+	return processor.RedactMode(c.Mode)
+}
+
+func (c SampleConfig) sampler() (processor.Sampler, error) {
+	mode := c.Mode
+	if mode == "" {
+		mode = string(processor.SampleDeterministic)
+	}
+
+	defaultRate := 1.0
+	if c.DefaultRate != nil {
+		defaultRate = *c.DefaultRate
+	}
+
+	return processor.NewSampler(processor.SampleMode(mode), defaultRate, c.Rules)
+}
+
+func (o OutputConfig) batch() processor.BatchOptions {
+	opts := processor.DefaultBatchOptions()
+
+	if o.BatchSize > 0 {
+		opts.Size = o.BatchSize
+	}
+
+	if o.FlushInterval > 0 {
+		opts.FlushInterval = o.FlushInterval
+	}
+
+	if o.MaxRetries != nil {
+		opts.MaxRetries = *o.MaxRetries
+	}
+
+	return opts
+}
+
+func (b BalanceConfig) options() (balancer.Options, error) {
+	opts := balancer.DefaultOptions()
+	opts.Strategy = balancer.Strategy(b.Strategy)
+	opts.HealthInterval = b.HealthInterval
+	opts.FailureThreshold = b.FailureThreshold
+
+	if opts.Strategy != balancer.RoundRobin && opts.Strategy != balancer.LeastPending {
+		return opts, fmt.Errorf("%w: %q", balancer.ErrUnknownStrategy, b.Strategy)
+	}
 
-	return config
+	return opts, nil
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := map[string]struct {
+		giveConfig string
+		wantErrs   []string
+	}{
+		"valid config": {
+			giveConfig: `
+outputs:
+  - urls: [http://localhost:8080]
+sources:
+  - name: app
+    watch_dirs: [./testdata]
+    transforms:
+      - redact: {detectors: [email]}
+`,
+		},
+		"invalid keys": {
+			giveConfig: `
+sources:
+  - name: app
+    watch_dirs: [./testdata]
+    decoder: {type: xml}
+    transforms:
+      - sample: {rules: {debug: 5}}
+    outputs:
+      - urls: [ftp://localhost]
+      - urls: ["stdout:"]
+        api_key_file: ./key
+      - urls: [http://localhost:8080]
+        tls: {cert_file: ./client.crt}
+  - name: app
+`,
+			wantErrs: []string{
+				`sources[0].decoder.type: unknown decoder "xml"`,
+				`sources[0].transforms[0].sample: sample rate must be between 0 and 1: "debug" rate 5`,
+				`sources[0].outputs[0].urls[0]: unsupported output "ftp://localhost"`,
+				`sources[0].outputs[1].api_key_file: only receiver outputs support API keys`,
+				`sources[0].outputs[2].urls[0]: TLS settings require https URL`,
+				`sources[0].outputs[2].tls: both certificate and key files are required`,
+				`sources[1].name: duplicate source "app"`,
+				`sources[1].watch_dirs: at least one directory is required`,
+				`sources[1].outputs: at least one output is required`,
+			},
+		},
+		"unknown field": {
+			giveConfig: `
+sources:
+  - name: app
+    watch_dir: [./testdata]
+`,
+			wantErrs: []string{"line 4: field watch_dir not found"},
+		},
+		"environment variables": {
+			giveConfig: `
+sources:
+  - name: app
+    watch_dirs: [./testdata]
+    outputs:
+      - urls: [${TEST_RECEIVER_ADDR}]
+`,
+			wantErrs: []string{`sources[0].outputs[0].urls[0]: unsupported output "tcp://localhost"`},
+		},
+	}
+
+	t.Setenv("TEST_RECEIVER_ADDR", "tcp://localhost")
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "shipper.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.giveConfig), 0o600))
+
+			_, err := loadConfig(path)
+
+			if len(test.wantErrs) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			for _, wantErr := range test.wantErrs {
+				require.ErrorContains(t, err, wantErr)
+			}
+		})
+	}
+}
@@ -2,27 +2,50 @@ package main
 
 import (
 	"context"
-	"io"
 	"log/slog"
+	"net/http"
 	"os"
-	"time"
 
-	"github.com/dyptan-io/log-management/v2/api"
-	"github.com/dyptan-io/log-management/v2/internal/platform/fs"
+	"github.com/dyptan-io/log-management/v2/internal/platform/config"
 	"github.com/dyptan-io/log-management/v2/internal/platform/server"
-	"github.com/dyptan-io/log-management/v2/internal/processor"
 )
 
 func main() {
-	config := readConfig()
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
-	receiverClient, _ := api.NewClient(config.ReceiverAddr)
-	reader := fs.Watch(config.WatchDirs, time.Second, logger)
-	handler := processor.New(processor.DecoderJSON{}, receiverClient)
-	listener := server.NewStreamReader(io.NopCloser(reader), handler.Process)
+	cfg, err := readConfig()
+	if err != nil {
+		logger.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	m := newPipelineMetrics()
+
+	sources, err := newSupervisor(cfg, m, logger)
+	if err != nil {
+		logger.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	config.ReloadOnSignal(ctx, sources.Reload, logger)
+
+	listeners := []server.Listener{sources}
+
+	if cfg.Admin.Addr != "" {
+		listeners = append(listeners, &http.Server{
+			Addr:    cfg.Admin.Addr,
+			Handler: newAdminHandler(sources, m, logger),
+		})
+	}
+
+	// Shutting down the sources flushes their pending batches.
+	err = server.New(server.NewGroup(listeners...), logger).Serve(ctx)
+
+	cancel()
 
-	if err := server.New(listener, logger).Serve(context.Background()); err != nil {
+	if err != nil {
 		logger.Error("Error occurred", "error", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"github.com/dyptan-io/log-management/v2/internal/platform/metrics"
+	"github.com/dyptan-io/log-management/v2/internal/platform/server"
+	"github.com/dyptan-io/log-management/v2/internal/processor"
+)
+
+// Reasons entries are dropped for, used as the reason label.
+const (
+	dropReasonDecode    = "decode"
+	dropReasonTransform = "transform"
+	dropReasonOutput    = "output"
+)
+
+// pipelineMetrics are metrics of all sources. They outlive sources restarted on
+// configuration reload, so that counters are not reset.
+type pipelineMetrics struct {
+	registry *metrics.Registry
+
+	linesRead metrics.CounterVec
+	decoded   metrics.CounterVec
+	dropped   metrics.CounterVec
+	sent      metrics.CounterVec
+	retried   metrics.CounterVec
+	pending   metrics.GaugeVec
+}
+
+func newPipelineMetrics() *pipelineMetrics {
+	r := metrics.NewRegistry()
+
+	return &pipelineMetrics{
+		registry:  r,
+		linesRead: r.NewCounterVec("shipper_lines_read_total", "Lines read from watched files.", "source"),
+		decoded:   r.NewCounterVec("shipper_entries_decoded_total", "Log entries decoded from read lines.", "source"),
+		dropped:   r.NewCounterVec("shipper_entries_dropped_total", "Log entries dropped by the pipeline.", "source", "reason"),
+		sent:      r.NewCounterVec("shipper_entries_sent_total", "Log entries sent to outputs.", "source", "output"),
+		retried:   r.NewCounterVec("shipper_entries_retried_total", "Log entries sent again after failures.", "source", "output"),
+		pending:   r.NewGaugeVec("shipper_spool_entries", "Log entries waiting in batches to be sent.", "source", "output"),
+	}
+}
+
+// source returns metrics of the source processor.
+func (m *pipelineMetrics) source(name string) processor.Metrics {
+	if m == nil {
+		return processor.Metrics{}
+	}
+
+	return processor.Metrics{
+		Decoded: m.decoded.With(name),
+		Invalid: m.dropped.With(name, dropReasonDecode),
+		Dropped: m.dropped.With(name, dropReasonTransform),
+	}
+}
+
+// output returns metrics of the source output.
+func (m *pipelineMetrics) output(source, output string) processor.Metrics {
+	if m == nil {
+		return processor.Metrics{}
+	}
+
+	return processor.Metrics{
+		Dropped: m.dropped.With(source, dropReasonOutput),
+		Sent:    m.sent.With(source, output),
+		Retried: m.retried.With(source, output),
+		Pending: m.pending.With(source, output),
+	}
+}
+
+// countLines returns a handler counting lines passed to the next one.
+func (m *pipelineMetrics) countLines(source string, next server.Handler) server.Handler {
+	if m == nil {
+		return next
+	}
+
+	counter := m.linesRead.With(source)
+
+	return func(msg server.Message) error {
+		counter.Inc()
+		return next(msg)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/auth"
+	"github.com/dyptan-io/log-management/v2/internal/platform/balancer"
+	"github.com/dyptan-io/log-management/v2/internal/platform/certs"
+	"github.com/dyptan-io/log-management/v2/internal/processor"
+	"github.com/dyptan-io/log-management/v2/internal/service"
+)
+
+// pinger is an output that can check whether its destination is reachable.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// newOutput returns a Router sending entries of the source to all configured outputs,
+// along with the outputs that the readiness of the source depends on.
+func newOutput(source string, configs []OutputConfig, balance balancer.Options, m *pipelineMetrics, logger *slog.Logger) (processor.Output, []pinger, error) {
+	var (
+		routes  = make([]processor.Route, 0, len(configs))
+		pingers []pinger
+	)
+
+	// Outputs that are already opened are closed if any of the others fails.
+	fail := func(err error) (processor.Output, []pinger, error) {
+		return nil, nil, errors.Join(err, processor.NewRouter(routes...).Close(context.Background()))
+	}
+
+	for _, config := range configs {
+		name := strings.Join(config.URLs, ",")
+		route := processor.Route{Final: config.Final}
+
+		// Conditions are parsed before the output is opened, so that it isn't left open.
+		for _, match := range config.Match {
+			c, err := processor.ParseCondition(match)
+			if err != nil {
+				return fail(fmt.Errorf("configuring %q output: %w", name, err))
+			}
+
+			route.Conditions = append(route.Conditions, c)
+		}
+
+		out, err := newDestination(config, balance, logger.With("output", name))
+		if err != nil {
+			return fail(err)
+		}
+
+		if p, ok := out.(pinger); ok {
+			pingers = append(pingers, p)
+		}
+
+		opts := config.batch()
+		opts.Metrics = m.output(source, name)
+
+		route.Output = processor.NewBatchOutput(out, opts, logger.With("output", name))
+		routes = append(routes, route)
+	}
+
+	return processor.NewRouter(routes...), pingers, nil
+}
+
+func newDestination(config OutputConfig, balance balancer.Options, logger *slog.Logger) (processor.Output, error) {
+	urls, rawURL := config.URLs, config.URLs[0]
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing output URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		key, err := config.apiKey()
+		if err != nil {
+			return nil, err
+		}
+
+		httpClient, err := newHTTPClient(config.TLS)
+		if err != nil {
+			return nil, err
+		}
+
+		opts := []api.ClientOption{api.WithHTTPClient(httpClient)}
+
+		if key != "" {
+			opts = append(opts, api.WithRequestEditorFn(auth.BearerToken(key)))
+		}
+
+		if config.Tenant != "" {
+			opts = append(opts, api.WithRequestEditorFn(func(_ context.Context, r *http.Request) error {
+				r.Header.Set(service.TenantHeader, config.Tenant)
+				return nil
+			}))
+		}
+
+		if len(urls) == 1 {
+			client, err := api.NewClient(rawURL, opts...)
+			if err != nil {
+				return nil, fmt.Errorf("creating receiver client: %w", err)
+			}
+
+			return processor.NewReceiverOutput(client), nil
+		}
+
+		b, err := balancer.New(urls, httpClient, balance, logger)
+		if err != nil {
+			return nil, fmt.Errorf("configuring receivers balancing: %w", err)
+		}
+
+		// The server address is replaced by the balancer with the one of the chosen receiver.
+		client, err := api.NewClient("http://receiver", append(opts, api.WithHTTPClient(b))...)
+		if err != nil {
+			return nil, fmt.Errorf("creating receiver client: %w", err)
+		}
+
+		return balancedOutput{ReceiverOutput: processor.NewReceiverOutput(client), balancer: b}, nil
+	case "file":
+		path := u.Path
+		if path == "" {
+			// Relative paths like file:out.ndjson are opaque URLs.
+			path = u.Opaque
+		}
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("opening output file: %w", err)
+		}
+
+		return processor.NewWriterOutput(file), nil
+	case "stdout":
+		return processor.NewWriterOutput(nopWriteCloser{os.Stdout}), nil
+	default:
+		return nil, fmt.Errorf("unsupported output %q", rawURL)
+	}
+}
+
+// newHTTPClient returns a client of receivers, with the TLS settings if they are given.
+func newHTTPClient(files certs.Files) (*http.Client, error) {
+	if files == (certs.Files{}) {
+		return http.DefaultClient, nil
+	}
+
+	// Rotated client certificates and CA bundles are reloaded on the next TLS handshake.
+	certificates, err := certs.NewReloader(files)
+	if err != nil {
+		return nil, fmt.Errorf("configuring TLS: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialTLSContext = certificates.DialTLSContext
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// balancedOutput stops balancer health checks once the output is closed.
+type balancedOutput struct {
+	processor.ReceiverOutput
+	balancer *balancer.Balancer
+}
+
+func (o balancedOutput) Close(ctx context.Context) error {
+	return errors.Join(o.ReceiverOutput.Close(ctx), o.balancer.Close())
+}
+
+// nopWriteCloser prevents closing of shared writers like stdout.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/fs"
+	"github.com/dyptan-io/log-management/v2/internal/platform/server"
+	"github.com/dyptan-io/log-management/v2/internal/processor"
+)
+
+// watchInterval is an interval of scanning watched directories.
+const watchInterval = time.Second
+
+type (
+	// sourceSpec is the complete configuration of a source pipeline, used to find out
+	// which sources are affected by the configuration changes.
+	sourceSpec struct {
+		Source  SourceConfig
+		Outputs []OutputConfig
+		Balance BalanceConfig
+	}
+
+	// source is a log source with its own processing pipeline.
+	source struct {
+		spec      sourceSpec
+		handler   server.Handler
+		output    processor.Output
+		receivers []pinger
+		multiline *processor.Multiline
+		logger    *slog.Logger
+
+		// Fields below are set once the source is started.
+		watcher  *fs.Watcher
+		listener server.Listener
+		cancel   context.CancelFunc
+		stopped  chan struct{}
+	}
+)
+
+// sourceSpecs returns pipeline specs of all configured sources by their names.
+func sourceSpecs(config Config) map[string]sourceSpec {
+	specs := make(map[string]sourceSpec, len(config.Sources))
+
+	for _, sc := range config.Sources {
+		outputs := sc.Outputs
+		if len(outputs) == 0 {
+			outputs = config.Outputs
+		}
+
+		specs[sc.Name] = sourceSpec{Source: sc, Outputs: outputs, Balance: config.Balance}
+	}
+
+	return specs
+}
+
+// newSource builds the source pipeline without starting it.
+func newSource(spec sourceSpec, m *pipelineMetrics, logger *slog.Logger) (*source, error) {
+	decoder, err := spec.Source.Decoder.decoder()
+	if err != nil {
+		return nil, err
+	}
+
+	transformers, err := newTransformers(spec.Source.Transforms)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := spec.Balance.options()
+	if err != nil {
+		return nil, err
+	}
+
+	name := spec.Source.Name
+
+	output, receivers, err := newOutput(name, spec.Outputs, balance, m, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &source{
+		spec:      spec,
+		output:    output,
+		receivers: receivers,
+		logger:    logger,
+	}
+
+	s.handler = processor.New(decoder, output, transformers...).WithMetrics(m.source(name)).Process
+
+	if spec.Source.Multiline != nil {
+		opts, err := spec.Source.Multiline.options()
+		if err != nil {
+			return nil, errors.Join(err, output.Close(context.Background()))
+		}
+
+		s.multiline = processor.NewMultiline(s.handler, opts, logger)
+		s.handler = s.multiline.Handle
+	}
+
+	s.handler = m.countLines(name, s.handler)
+
+	return s, nil
+}
+
+// start starts watching the source directories continuing from the given file offsets.
+// Errors the source fails with are sent to errsCh.
+func (s *source) start(offsets map[string]int64, errsCh chan<- error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.cancel = cancel
+	s.stopped = make(chan struct{})
+	s.watcher = fs.Watch(ctx, s.spec.Source.WatchDirs, offsets, watchInterval, s.logger)
+	s.listener = server.NewStreamReader(io.NopCloser(s.watcher), s.handler)
+
+	go func() {
+		defer close(s.stopped)
+
+		if err := s.listener.ListenAndServe(); err != nil {
+			select {
+			case errsCh <- fmt.Errorf("%q source has failed: %w", s.spec.Source.Name, err):
+			default:
+				s.logger.Error("source has failed", "error", err)
+			}
+		}
+	}()
+}
+
+// files returns the status of files read by the source.
+func (s *source) files() []fs.FileStatus {
+	if s.watcher == nil {
+		return nil
+	}
+
+	return s.watcher.Files()
+}
+
+// ping checks that all receivers the source sends entries to are reachable.
+func (s *source) ping(ctx context.Context) error {
+	var errs []error
+
+	for _, r := range s.receivers {
+		errs = append(errs, r.Ping(ctx))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%q source: %w", s.spec.Source.Name, err)
+	}
+
+	return nil
+}
+
+// stop stops watching the source directories, flushes pending messages and closes
+// outputs of the source. It returns offsets of the files handled so far.
+func (s *source) stop(ctx context.Context) (map[string]int64, error) {
+	var errs []error
+
+	if s.listener != nil {
+		// Files are no longer read, and lines read from them are handled before the listener stops,
+		// so that the next source continues right after the handled ones.
+		errs = append(errs, s.watcher.Close())
+		errs = append(errs, s.listener.Shutdown(ctx))
+
+		select {
+		case <-s.stopped:
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+		}
+
+		s.cancel()
+	}
+
+	if s.multiline != nil {
+		errs = append(errs, s.multiline.Flush())
+	}
+
+	errs = append(errs, s.output.Close(ctx))
+
+	var offsets map[string]int64
+
+	if s.watcher != nil {
+		offsets = s.watcher.Offsets()
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return offsets, fmt.Errorf("stopping %q source: %w", s.spec.Source.Name, err)
+	}
+
+	return offsets, nil
+}
+
+func newTransformers(configs []TransformConfig) ([]processor.Transformer, error) {
+	transformers := make([]processor.Transformer, 0, len(configs))
+
+	for _, config := range configs {
+		switch {
+		case config.Sample != nil:
+			sampler, err := config.Sample.sampler()
+			if err != nil {
+				return nil, fmt.Errorf("configuring sampling: %w", err)
+			}
+
+			transformers = append(transformers, sampler)
+		case config.RateLimit != nil:
+			transformers = append(transformers, processor.NewRateLimiter(config.RateLimit.Rate, config.RateLimit.Burst, config.RateLimit.KeyAttr))
+		case config.Redact != nil:
+			redactor, err := newRedactor(*config.Redact)
+			if err != nil {
+				return nil, fmt.Errorf("configuring redaction: %w", err)
+			}
+
+			transformers = append(transformers, redactor)
+		}
+	}
+
+	return transformers, nil
+}
+
+func newRedactor(config RedactConfig) (processor.Redactor, error) {
+	detectors, err := config.detectors()
+	if err != nil {
+		return processor.Redactor{}, err
+	}
+
+	var key []byte
+
+	if config.KeyFile != "" {
+		b, err := os.ReadFile(config.KeyFile)
+		if err != nil {
+			return processor.Redactor{}, fmt.Errorf("reading redaction key: %w", err)
+		}
+
+		key = bytes.TrimSpace(b)
+	}
+
+	return processor.NewRedactor(config.mode(), key, detectors...)
+}
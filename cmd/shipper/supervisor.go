@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/config"
+)
+
+// supervisor is a server.Listener that runs log sources and applies configuration
+// changes to them without restarting unaffected ones.
+type supervisor struct {
+	file    string
+	metrics *pipelineMetrics
+	logger  *slog.Logger
+	errsCh  chan error
+	done    chan struct{}
+	close   sync.Once
+
+	mu      sync.Mutex
+	sources map[string]*source
+}
+
+// newSupervisor returns a new instance of supervisor with pipelines of all configured sources.
+func newSupervisor(cfg Config, m *pipelineMetrics, logger *slog.Logger) (*supervisor, error) {
+	s := &supervisor{
+		file:    cfg.File,
+		metrics: m,
+		logger:  logger,
+		errsCh:  make(chan error, 1),
+		done:    make(chan struct{}),
+		sources: make(map[string]*source),
+	}
+
+	for name, spec := range sourceSpecs(cfg) {
+		src, err := newSource(spec, s.metrics, logger.With("source", name))
+		if err != nil {
+			s.stopAll(context.Background())
+			return nil, err
+		}
+
+		s.sources[name] = src
+	}
+
+	return s, nil
+}
+
+// ListenAndServe starts all sources and blocks until one of them fails or
+// the supervisor is shut down.
+func (s *supervisor) ListenAndServe() error {
+	s.mu.Lock()
+
+	for _, src := range s.sources {
+		src.start(nil, s.errsCh)
+	}
+
+	s.mu.Unlock()
+
+	select {
+	case err := <-s.errsCh:
+		return err
+	case <-s.done:
+		return nil
+	}
+}
+
+// Shutdown stops all sources.
+func (s *supervisor) Shutdown(ctx context.Context) error {
+	s.close.Do(func() { close(s.done) })
+
+	return s.stopAll(ctx)
+}
+
+// Reload reads the configuration file again, restarts sources whose configuration has changed,
+// stops removed sources and starts new ones. Files of restarted sources are read from the
+// offsets they were stopped at. The current configuration keeps running if the new one is invalid.
+func (s *supervisor) Reload() error {
+	if s.file == "" {
+		return config.ErrNoConfigFile
+	}
+
+	cfg, err := loadConfig(s.file)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	specs := sourceSpecs(cfg)
+	changed := make(map[string]*source)
+
+	for name, spec := range specs {
+		if src, ok := s.sources[name]; ok && reflect.DeepEqual(src.spec, spec) {
+			continue
+		}
+
+		src, err := newSource(spec, s.metrics, s.logger.With("source", name))
+		if err != nil {
+			for _, src := range changed {
+				src.stop(context.Background())
+			}
+
+			return err
+		}
+
+		changed[name] = src
+	}
+
+	ctx := context.Background()
+
+	for name, src := range s.sources {
+		if _, ok := specs[name]; ok && changed[name] == nil {
+			continue
+		}
+
+		offsets, err := src.stop(ctx)
+		if err != nil {
+			s.logger.Error("stopping source", "source", name, "error", err)
+		}
+
+		delete(s.sources, name)
+
+		if next, ok := changed[name]; ok {
+			s.logger.Info("restarting source", "source", name)
+			next.start(offsets, s.errsCh)
+			s.sources[name] = next
+		} else {
+			s.logger.Info("source removed", "source", name)
+		}
+	}
+
+	for name, src := range changed {
+		if _, ok := s.sources[name]; !ok {
+			s.logger.Info("source added", "source", name)
+			src.start(nil, s.errsCh)
+			s.sources[name] = src
+		}
+	}
+
+	return nil
+}
+
+// Status returns the status of files read by every source, sorted by source names.
+func (s *supervisor) Status() []sourceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]sourceStatus, 0, len(s.sources))
+
+	for name, src := range s.sources {
+		status := sourceStatus{Name: name, Files: []fileStatus{}}
+
+		for _, f := range src.files() {
+			status.Files = append(status.Files, newFileStatus(f))
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	slices.SortFunc(statuses, func(a, b sourceStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return statuses
+}
+
+// Ready checks that receivers of all sources are reachable.
+func (s *supervisor) Ready(ctx context.Context) error {
+	s.mu.Lock()
+	sources := slices.Collect(maps.Values(s.sources))
+	s.mu.Unlock()
+
+	var errs []error
+
+	for _, src := range sources {
+		errs = append(errs, src.ping(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *supervisor) stopAll(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+
+	for name, src := range s.sources {
+		if _, err := src.stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+
+		delete(s.sources, name)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,214 @@
+// Package auth implements API-key authentication of HTTP requests.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/config"
+)
+
+// hashPrefix is a prefix of SHA-256 hashes of API keys.
+const hashPrefix = "sha256:"
+
+// Scopes of API keys.
+const (
+	// ScopeIngest allows sending log entries.
+	ScopeIngest Scope = "ingest"
+	// ScopeRead allows reading log entries.
+	ScopeRead Scope = "read"
+	// ScopeAdmin allows managing the service, e.g. reloading its configuration.
+	ScopeAdmin Scope = "admin"
+)
+
+var (
+	// ErrBadKeyHash is an error when the API key hash has unexpected format.
+	ErrBadKeyHash = errors.New("API key hash must be sha256:<64 hex digits>")
+	// ErrUnknownScope is an error when the scope isn't supported.
+	ErrUnknownScope = errors.New("unknown scope")
+)
+
+type (
+	// Scope is a set of operations an API key is allowed to perform.
+	Scope string
+
+	// Key is an API key stored as its hash.
+	Key struct {
+		Name   string  `yaml:"name"`
+		Hash   string  `yaml:"hash"`
+		Scopes []Scope `yaml:"scopes"`
+		// Tenant confines requests with the key to the tenant, if it's set.
+		Tenant string `yaml:"tenant"`
+		// Role selects the policy restricting entries readable with the key, if it's set.
+		Role string `yaml:"role"`
+	}
+
+	// Keys is a set of API keys.
+	Keys struct {
+		Keys []Key `yaml:"keys"`
+	}
+
+	// contextKey is a type of context keys of the package.
+	contextKey struct{}
+
+	// Authenticator is an HTTP middleware authenticating requests with API keys given
+	// as the bearer token of the Authorization header or in the X-API-Key header.
+	Authenticator struct {
+		keys atomic.Pointer[Keys]
+	}
+)
+
+// HashKey returns the hash of the API key to be stored in the keys file.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// LoadKeys reads and validates the YAML or JSON file of API keys.
+func LoadKeys(path string) (*Keys, error) {
+	var keys Keys
+
+	if err := config.Load(path, &keys); err != nil {
+		return nil, err
+	}
+
+	if err := keys.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid API keys file %s: %w", path, err)
+	}
+
+	return &keys, nil
+}
+
+// Validate checks all keys and reports all invalid ones. Valid hashes are normalized to
+// lowercase hex, as keys are found by comparing them with hashes of HashKey.
+func (k *Keys) Validate() error {
+	var errs []error
+
+	for i, key := range k.Keys {
+		hash, ok := strings.CutPrefix(key.Hash, hashPrefix)
+		if b, err := hex.DecodeString(hash); !ok || err != nil || len(b) != sha256.Size {
+			errs = append(errs, fmt.Errorf("keys[%d].hash: %w", i, ErrBadKeyHash))
+		} else {
+			k.Keys[i].Hash = hashPrefix + hex.EncodeToString(b)
+		}
+
+		for j, s := range key.Scopes {
+			if !slices.Contains([]Scope{ScopeIngest, ScopeRead, ScopeAdmin}, s) {
+				errs = append(errs, fmt.Errorf("keys[%d].scopes[%d]: %w: %q", i, j, ErrUnknownScope, s))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Find returns the stored key matching the given API key.
+func (k *Keys) Find(key string) (Key, bool) {
+	if key == "" {
+		return Key{}, false
+	}
+
+	hash := HashKey(key)
+
+	for _, stored := range k.Keys {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(stored.Hash)) == 1 {
+			return stored, true
+		}
+	}
+
+	return Key{}, false
+}
+
+// NewAuthenticator returns a new instance of Authenticator. Nil keys disable authentication.
+func NewAuthenticator(keys *Keys) *Authenticator {
+	a := &Authenticator{}
+	a.Update(keys)
+
+	return a
+}
+
+// Update replaces keys of the running middleware.
+func (a *Authenticator) Update(keys *Keys) {
+	a.keys.Store(keys)
+}
+
+// Require returns a middleware that allows only requests with API keys having the scope.
+// It responds with 401 Unauthorized if the key is missing or unknown, and with 403 Forbidden
+// if the key doesn't have the scope.
+func (a *Authenticator) Require(scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys := a.keys.Load()
+			if keys == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, ok := keys.Find(token(r))
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				writeError(w, http.StatusUnauthorized, "missing or invalid API key")
+
+				return
+			}
+
+			if !slices.Contains(key.Scopes, scope) {
+				writeError(w, http.StatusForbidden, fmt.Sprintf("API key %q doesn't have %q scope", key.Name, scope))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), key)))
+		})
+	}
+}
+
+// NewContext returns a copy of the context with the authenticated key.
+func NewContext(ctx context.Context, key Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext returns the key the request has been authenticated with, if any.
+func FromContext(ctx context.Context) (Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(Key)
+
+	return key, ok
+}
+
+// BearerToken returns a request editor of generated API clients that authenticates
+// requests with the API key.
+func BearerToken(key string) func(context.Context, *http.Request) error {
+	return func(_ context.Context, r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer "+key)
+
+		return nil
+	}
+}
+
+func token(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {msg}})
+}
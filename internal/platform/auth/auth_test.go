@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator_Require(t *testing.T) {
+	keys := &Keys{Keys: []Key{
+		{Name: "shipper", Hash: HashKey("ingest-key"), Scopes: []Scope{ScopeIngest}},
+		{Name: "reader", Hash: HashKey("read-key"), Scopes: []Scope{ScopeRead}},
+	}}
+
+	tests := map[string]struct {
+		giveKeys   *Keys
+		giveHeader http.Header
+		wantStatus int
+	}{
+		"bearer token with scope": {
+			giveKeys:   keys,
+			giveHeader: http.Header{"Authorization": {"Bearer ingest-key"}},
+			wantStatus: http.StatusOK,
+		},
+		"api key header with scope": {
+			giveKeys:   keys,
+			giveHeader: http.Header{"X-Api-Key": {"ingest-key"}},
+			wantStatus: http.StatusOK,
+		},
+		"missing key": {
+			giveKeys:   keys,
+			wantStatus: http.StatusUnauthorized,
+		},
+		"unknown key": {
+			giveKeys:   keys,
+			giveHeader: http.Header{"Authorization": {"Bearer other"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		"basic auth": {
+			giveKeys:   keys,
+			giveHeader: http.Header{"Authorization": {"Basic ingest-key"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		"key without scope": {
+			giveKeys:   keys,
+			giveHeader: http.Header{"Authorization": {"Bearer read-key"}},
+			wantStatus: http.StatusForbidden,
+		},
+		"disabled": {
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := NewAuthenticator(test.giveKeys).Require(ScopeIngest)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/logs", nil)
+			r.Header = test.giveHeader
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			require.Equal(t, test.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	tests := map[string]struct {
+		giveContent string
+		wantErr     bool
+	}{
+		"valid": {
+			giveContent: "keys:\n  - name: shipper\n    hash: " + HashKey("key") + "\n    scopes: [ingest, read]\n",
+		},
+		"uppercase hash": {
+			giveContent: "keys:\n  - name: shipper\n    hash: " + hashPrefix + strings.ToUpper(strings.TrimPrefix(HashKey("key"), hashPrefix)) +
+				"\n    scopes: [ingest]\n",
+		},
+		"plain key": {
+			giveContent: "keys:\n  - name: shipper\n    hash: key\n    scopes: [ingest]\n",
+			wantErr:     true,
+		},
+		"unknown scope": {
+			giveContent: "keys:\n  - name: shipper\n    hash: " + HashKey("key") + "\n    scopes: [write]\n",
+			wantErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.giveContent), 0o600))
+
+			keys, err := LoadKeys(path)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			key, ok := keys.Find("key")
+			require.True(t, ok)
+			require.Equal(t, "shipper", key.Name)
+		})
+	}
+}
@@ -0,0 +1,300 @@
+// Package balancer implements client-side load balancing and failover of HTTP
+// requests across multiple endpoints.
+package balancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/async"
+)
+
+// Balancing strategies supported by Balancer.
+const (
+	// RoundRobin sends requests to healthy endpoints in turn.
+	RoundRobin Strategy = "round-robin"
+	// LeastPending sends requests to the healthy endpoint with the fewest in-flight requests.
+	LeastPending Strategy = "least-pending"
+)
+
+var (
+	// ErrNoEndpoints is an error when no endpoints are configured.
+	ErrNoEndpoints = errors.New("no endpoints configured")
+	// ErrNoHealthyEndpoints is an error when all endpoints are ejected.
+	ErrNoHealthyEndpoints = errors.New("no healthy endpoints available")
+	// ErrUnknownStrategy is an error when the balancing strategy is not supported.
+	ErrUnknownStrategy = errors.New("unknown balancing strategy")
+)
+
+type (
+	// Strategy defines how Balancer picks an endpoint for a request.
+	Strategy string
+
+	// Doer performs HTTP requests. The standard http.Client implements this interface.
+	Doer interface {
+		Do(req *http.Request) (*http.Response, error)
+	}
+
+	// Options contains Balancer settings.
+	Options struct {
+		Strategy Strategy
+		// HealthPath is requested on every endpoint to check whether it is healthy.
+		HealthPath string
+		// HealthInterval is the interval between health checks.
+		HealthInterval time.Duration
+		// FailureThreshold is the number of consecutive failures that ejects the endpoint.
+		FailureThreshold int
+	}
+
+	// Balancer is a Doer that spreads requests across endpoints. Endpoints that fail
+	// consecutively are ejected (the circuit is opened) and reinstated once their
+	// health check succeeds again.
+	Balancer struct {
+		endpoints []*endpoint
+		doer      Doer
+		opts      Options
+		logger    *slog.Logger
+		next      atomic.Uint64
+		cancel    context.CancelFunc
+	}
+
+	endpoint struct {
+		url     *url.URL
+		pending atomic.Int64
+
+		mu       sync.Mutex
+		failures int
+		ejected  bool
+	}
+)
+
+// DefaultOptions returns Balancer settings suitable for the logs receiver.
+func DefaultOptions() Options {
+	return Options{
+		Strategy:         RoundRobin,
+		HealthPath:       "/health",
+		HealthInterval:   5 * time.Second,
+		FailureThreshold: 3,
+	}
+}
+
+// New returns a new instance of Balancer for the endpoint base URLs and starts health checks.
+// Requests passed to Balancer have their scheme and host replaced by the ones of the chosen
+// endpoint, and their path prefixed by the endpoint path.
+func New(addrs []string, doer Doer, opts Options, logger *slog.Logger) (*Balancer, error) {
+	if len(addrs) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	if opts.Strategy != RoundRobin && opts.Strategy != LeastPending {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStrategy, opts.Strategy)
+	}
+
+	b := &Balancer{
+		doer:   doer,
+		opts:   opts,
+		logger: logger,
+	}
+
+	for _, addr := range addrs {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return nil, fmt.Errorf("parsing endpoint %q: %w", addr, err)
+		}
+
+		b.endpoints = append(b.endpoints, &endpoint{url: u})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	if opts.HealthInterval > 0 {
+		async.Schedule(ctx, opts.HealthInterval, b.checkHealth, logger)
+	}
+
+	return b, nil
+}
+
+// Do sends the request to one of healthy endpoints. If the endpoint can't be reached or can't serve
+// requests, the request is retried on the next one, as long as its body can be replayed. The last gateway
+// error response, if any, is returned if none of the endpoints can serve the request. Overload responses
+// are returned as they are, so that the client backs off.
+func (b *Balancer) Do(req *http.Request) (*http.Response, error) {
+	var (
+		lastResp *http.Response
+		lastErr  error
+	)
+
+	tried := make(map[*endpoint]bool, len(b.endpoints))
+
+	for range b.endpoints {
+		e := b.pick(tried)
+		if e == nil {
+			break
+		}
+
+		tried[e] = true
+
+		resp, err := b.do(req, e)
+
+		switch {
+		case err != nil:
+			lastErr = err
+		case unavailable(resp):
+			discard(lastResp)
+			lastResp = resp
+		default:
+			discard(lastResp)
+			return resp, nil
+		}
+
+		if req.Body != nil && req.GetBody == nil {
+			break
+		}
+	}
+
+	if lastResp != nil {
+		return lastResp, nil
+	}
+
+	if lastErr == nil {
+		return nil, ErrNoHealthyEndpoints
+	}
+
+	return nil, lastErr
+}
+
+// Close stops health checks.
+func (b *Balancer) Close() error {
+	b.cancel()
+	return nil
+}
+
+func (b *Balancer) do(req *http.Request, e *endpoint) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = e.url.Scheme
+	r.URL.Host = e.url.Host
+	r.URL.Path = strings.TrimSuffix(e.url.Path, "/") + req.URL.Path
+	r.Host = ""
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+
+		r.Body = body
+	}
+
+	e.pending.Add(1)
+	defer e.pending.Add(-1)
+
+	resp, err := b.doer.Do(r)
+
+	b.record(e, err == nil && !unavailable(resp))
+
+	return resp, err
+}
+
+// discard drains and closes the body of the response, if any, so that the connection can be reused.
+func discard(resp *http.Response) {
+	if resp != nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// unavailable reports whether the response is a gateway error, which means the endpoint is there
+// but can't serve requests. Overload responses asking to retry later, i.e. 503 with Retry-After, are
+// not: the endpoint is healthy and the client is expected to slow down rather than go elsewhere.
+func unavailable(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return resp.Header.Get("Retry-After") == ""
+	}
+
+	return resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusGatewayTimeout
+}
+
+// pick returns a healthy endpoint not tried yet, or nil if there is none.
+func (b *Balancer) pick(tried map[*endpoint]bool) *endpoint {
+	start := b.next.Add(1)
+
+	var best *endpoint
+
+	for i := range b.endpoints {
+		e := b.endpoints[(start+uint64(i))%uint64(len(b.endpoints))]
+		if tried[e] || e.isEjected() {
+			continue
+		}
+
+		if b.opts.Strategy == RoundRobin {
+			return e
+		}
+
+		if best == nil || e.pending.Load() < best.pending.Load() {
+			best = e
+		}
+	}
+
+	return best
+}
+
+func (b *Balancer) record(e *endpoint, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if ok {
+		if e.ejected {
+			b.logger.Info("endpoint reinstated", "endpoint", e.url.String())
+		}
+
+		e.failures = 0
+		e.ejected = false
+
+		return
+	}
+
+	e.failures++
+
+	if !e.ejected && e.failures >= b.opts.FailureThreshold {
+		b.logger.Warn("endpoint ejected", "endpoint", e.url.String(), "failures", e.failures)
+		e.ejected = true
+	}
+}
+
+func (b *Balancer) checkHealth(ctx context.Context) error {
+	for _, e := range b.endpoints {
+		u := *e.url
+		u.Path = strings.TrimSuffix(u.Path, "/") + b.opts.HealthPath
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := b.doer.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		b.record(e, err == nil && resp.StatusCode == http.StatusOK)
+	}
+
+	return nil
+}
+
+func (e *endpoint) isEjected() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.ejected
+}
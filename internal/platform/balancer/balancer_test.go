@@ -0,0 +1,214 @@
+package balancer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, status *atomic.Int32, hits *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			hits.Add(1)
+		}
+
+		w.WriteHeader(int(status.Load()))
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestBalancer_Do(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var status1, status2, hits1, hits2 atomic.Int32
+
+	status1.Store(http.StatusOK)
+	status2.Store(http.StatusOK)
+
+	srv1 := newTestServer(t, &status1, &hits1)
+	srv2 := newTestServer(t, &status2, &hits2)
+
+	opts := DefaultOptions()
+	opts.HealthInterval = 0
+	opts.FailureThreshold = 2
+
+	b, err := New([]string{srv1.URL, srv2.URL}, http.DefaultClient, opts, logger)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { b.Close() })
+
+	send := func() int {
+		req, err := http.NewRequest(http.MethodPost, "http://receiver/v1/logs", bytes.NewReader([]byte("[]")))
+		require.NoError(t, err)
+
+		resp, err := b.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	// Round-robin across healthy endpoints.
+	for range 4 {
+		require.Equal(t, http.StatusOK, send())
+	}
+
+	require.EqualValues(t, 2, hits1.Load())
+	require.EqualValues(t, 2, hits2.Load())
+
+	// Requests fail over to the next endpoint if one can't serve them, and the failing endpoint
+	// is ejected after consecutive failures.
+	status1.Store(http.StatusServiceUnavailable)
+
+	for range 4 {
+		require.Equal(t, http.StatusOK, send())
+	}
+
+	require.True(t, b.endpoints[0].isEjected())
+	require.EqualValues(t, 4, hits1.Load())
+	require.EqualValues(t, 6, hits2.Load())
+
+	for range 2 {
+		require.Equal(t, http.StatusOK, send())
+	}
+
+	require.EqualValues(t, 4, hits1.Load())
+
+	// The endpoint is reinstated once its health check succeeds.
+	status1.Store(http.StatusOK)
+	require.NoError(t, b.checkHealth(context.Background()))
+	require.False(t, b.endpoints[0].isEjected())
+
+	// Requests fail over to the next endpoint if one is unreachable.
+	srv2.Close()
+
+	for range 2 {
+		require.Equal(t, http.StatusOK, send())
+	}
+
+	require.EqualValues(t, 6, hits1.Load())
+
+	// The gateway error is returned if no endpoint can serve requests.
+	status1.Store(http.StatusBadGateway)
+	require.Equal(t, http.StatusBadGateway, send())
+}
+
+func TestBalancer_DoLeastPending(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var status, hits1, hits2 atomic.Int32
+
+	status.Store(http.StatusOK)
+
+	srv1 := newTestServer(t, &status, &hits1)
+	srv2 := newTestServer(t, &status, &hits2)
+
+	opts := DefaultOptions()
+	opts.Strategy = LeastPending
+	opts.HealthInterval = 0
+
+	b, err := New([]string{srv1.URL, srv2.URL}, http.DefaultClient, opts, logger)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { b.Close() })
+
+	// Pretend the second endpoint is busy.
+	b.endpoints[1].pending.Store(10)
+
+	for range 3 {
+		req, err := http.NewRequest(http.MethodGet, "http://receiver/v1/logs", nil)
+		require.NoError(t, err)
+
+		resp, err := b.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	require.EqualValues(t, 3, hits1.Load())
+	require.EqualValues(t, 0, hits2.Load())
+}
+
+func TestBalancer_DoNotReplayable(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var status, hits1, hits2 atomic.Int32
+
+	status.Store(http.StatusBadGateway)
+
+	srv1 := newTestServer(t, &status, &hits1)
+	srv2 := newTestServer(t, &status, &hits2)
+
+	opts := DefaultOptions()
+	opts.HealthInterval = 0
+
+	b, err := New([]string{srv1.URL, srv2.URL}, http.DefaultClient, opts, logger)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { b.Close() })
+
+	// Requests with bodies that can't be replayed are not retried.
+	req, err := http.NewRequest(http.MethodPost, "http://receiver/v1/logs", io.NopCloser(bytes.NewReader([]byte("[]"))))
+	require.NoError(t, err)
+
+	resp, err := b.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	require.EqualValues(t, 1, hits1.Load()+hits2.Load())
+}
+
+func TestBalancer_DoOverloaded(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var hits atomic.Int32
+
+	// Both endpoints ask clients to slow down, as the receiver does once it's overloaded.
+	overloaded := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	srv1 := httptest.NewServer(overloaded)
+	t.Cleanup(srv1.Close)
+
+	srv2 := httptest.NewServer(overloaded)
+	t.Cleanup(srv2.Close)
+
+	opts := DefaultOptions()
+	opts.HealthInterval = 0
+	opts.FailureThreshold = 2
+
+	b, err := New([]string{srv1.URL, srv2.URL}, http.DefaultClient, opts, logger)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { b.Close() })
+
+	// Overload responses are passed through without failing over or ejecting endpoints.
+	for range 6 {
+		req, err := http.NewRequest(http.MethodPost, "http://receiver/v1/logs", bytes.NewReader([]byte("[]")))
+		require.NoError(t, err)
+
+		resp, err := b.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.Equal(t, "1", resp.Header.Get("Retry-After"))
+	}
+
+	require.EqualValues(t, 6, hits.Load())
+	require.False(t, b.endpoints[0].isEjected())
+	require.False(t, b.endpoints[1].isEjected())
+}
@@ -0,0 +1,211 @@
+// Package certs implements TLS configuration with certificates reloaded on rotation.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// dialTimeout is a timeout of connecting to servers, as the one of http.DefaultTransport.
+const dialTimeout = 30 * time.Second
+
+var (
+	// ErrMissingKey is an error when the certificate is given without its private key or vice versa.
+	ErrMissingKey = errors.New("both certificate and key files are required")
+	// ErrNoCertificates is an error when the CA bundle has no PEM encoded certificates.
+	ErrNoCertificates = errors.New("no certificates found")
+)
+
+type (
+	// Files are paths of PEM encoded TLS credentials.
+	Files struct {
+		// CertFile and KeyFile are the own certificate and its private key.
+		CertFile string `yaml:"cert_file"`
+		KeyFile  string `yaml:"key_file"`
+		// CAFile is a bundle of certificates the peer is verified against: of clients
+		// on the server side, or of servers on the client side.
+		CAFile string `yaml:"ca_file"`
+	}
+
+	// Reloader keeps the TLS credentials loaded from files and reloads them
+	// once any of the files is modified, so certificates can be rotated without restarts.
+	Reloader struct {
+		files Files
+
+		mu      sync.Mutex
+		cert    *tls.Certificate
+		pool    *x509.CertPool
+		modTime time.Time
+	}
+)
+
+// Validate checks that the files are given consistently.
+func (f Files) Validate() error {
+	if (f.CertFile == "") != (f.KeyFile == "") {
+		return ErrMissingKey
+	}
+
+	return nil
+}
+
+// NewReloader returns a new instance of Reloader with the credentials loaded.
+func NewReloader(files Files) (*Reloader, error) {
+	if err := files.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{files: files}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload loads the credentials again if any of the files has been modified since the last load.
+// The current credentials are kept if the new ones are invalid.
+func (r *Reloader) Reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !modTime.After(r.modTime) {
+		return nil
+	}
+
+	var (
+		cert *tls.Certificate
+		pool *x509.CertPool
+	)
+
+	if r.files.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+		if err != nil {
+			return fmt.Errorf("loading TLS certificate: %w", err)
+		}
+
+		cert = &c
+	}
+
+	if r.files.CAFile != "" {
+		pem, err := os.ReadFile(r.files.CAFile)
+		if err != nil {
+			return fmt.Errorf("reading CA bundle: %w", err)
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("parsing CA bundle %s: %w", r.files.CAFile, ErrNoCertificates)
+		}
+	}
+
+	r.cert, r.pool, r.modTime = cert, pool, modTime
+
+	return nil
+}
+
+// ServerConfig returns TLS configuration of servers. Clients are required to present
+// a certificate signed by the CA if the CA bundle is given.
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			if cert == nil {
+				return nil, ErrMissingKey
+			}
+
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+			}
+
+			if pool != nil {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = pool
+			}
+
+			return cfg, nil
+		},
+	}
+}
+
+// ClientConfig returns TLS configuration of clients. Servers are verified against the CA
+// bundle if it's given, or against the system roots otherwise. The own certificate is
+// presented if the server asks for it. The CA bundle is the one loaded by the time of
+// the call, see DialTLSContext for connections verified against the rotated ones.
+func (r *Reloader) ClientConfig() *tls.Config {
+	_, pool := r.current()
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pool,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert, _ := r.current(); cert != nil {
+				return cert, nil
+			}
+
+			return &tls.Certificate{}, nil
+		},
+	}
+}
+
+// DialTLSContext connects to the address, e.g. for http.Transport, with TLS configuration of
+// clients made for every connection, so that servers are verified against the rotated CA bundle.
+func (r *Reloader) DialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := r.ClientConfig()
+	cfg.ServerName = host
+
+	d := tls.Dialer{NetDialer: &net.Dialer{Timeout: dialTimeout, KeepAlive: dialTimeout}, Config: cfg}
+
+	return d.DialContext(ctx, network, addr)
+}
+
+// current reloads modified credentials and returns them. Reload errors are ignored,
+// e.g. if files are being replaced, to keep serving with the current credentials.
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	_ = r.Reload()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.cert, r.pool
+}
+
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, name := range []string{r.files.CertFile, r.files.KeyFile, r.files.CAFile} {
+		if name == "" {
+			continue
+		}
+
+		fi, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,225 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue writes a certificate signed by the CA and its key to the directory.
+func (ca testCA) issue(t *testing.T, dir, name string, serial int64) Files {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	files := Files{
+		CertFile: filepath.Join(dir, name+".crt"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+
+	require.NoError(t, os.WriteFile(files.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(files.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(files.CAFile, ca.pem, 0o600))
+
+	return files
+}
+
+func TestReloader_MutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+
+	serverFiles := ca.issue(t, dir, "server", 2)
+	clientFiles := ca.issue(t, dir, "client", 3)
+
+	server, err := NewReloader(serverFiles)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = server.ServerConfig()
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	tests := map[string]struct {
+		giveFiles Files
+		wantErr   bool
+	}{
+		"client certificate": {
+			giveFiles: clientFiles,
+		},
+		"missing client certificate": {
+			giveFiles: Files{CAFile: clientFiles.CAFile},
+			wantErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client, err := NewReloader(test.giveFiles)
+			require.NoError(t, err)
+
+			httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: client.ClientConfig()}}
+
+			resp, err := httpClient.Get(srv.URL)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+		})
+	}
+}
+
+func TestReloader_Rotation(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+
+	files := ca.issue(t, dir, "server", 2)
+
+	r, err := NewReloader(Files{CertFile: files.CertFile, KeyFile: files.KeyFile})
+	require.NoError(t, err)
+
+	serial := func() int64 {
+		cfg, err := r.ServerConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+		require.NoError(t, err)
+
+		cert, err := x509.ParseCertificate(cfg.Certificates[0].Certificate[0])
+		require.NoError(t, err)
+
+		return cert.SerialNumber.Int64()
+	}
+
+	require.EqualValues(t, 2, serial())
+
+	ca.issue(t, dir, "server", 4)
+
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(files.CertFile, future, future))
+
+	require.EqualValues(t, 4, serial())
+
+	// Invalid files are ignored until they are fixed.
+	require.NoError(t, os.WriteFile(files.KeyFile, []byte("invalid"), 0o600))
+	require.NoError(t, os.Chtimes(files.KeyFile, future.Add(time.Minute), future.Add(time.Minute)))
+
+	require.EqualValues(t, 4, serial())
+}
+
+func TestReloader_DialTLSContext(t *testing.T) {
+	rotated := newTestCA(t)
+
+	serverFiles := rotated.issue(t, t.TempDir(), "server", 2)
+
+	server, err := NewReloader(Files{CertFile: serverFiles.CertFile, KeyFile: serverFiles.KeyFile})
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = server.ServerConfig()
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, newTestCA(t).pem, 0o600))
+
+	client, err := NewReloader(Files{CAFile: caFile})
+	require.NoError(t, err)
+
+	httpClient := &http.Client{Transport: &http.Transport{DialTLSContext: client.DialTLSContext}}
+
+	_, err = httpClient.Get(srv.URL)
+	require.Error(t, err)
+
+	// The server is verified against the rotated CA bundle on the next connection.
+	require.NoError(t, os.WriteFile(caFile, rotated.pem, 0o600))
+
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(caFile, future, future))
+
+	resp, err := httpClient.Get(srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+}
+
+func TestNewReloader(t *testing.T) {
+	tests := map[string]struct {
+		giveFiles Files
+		wantErr   error
+	}{
+		"certificate without key": {
+			giveFiles: Files{CertFile: "server.crt"},
+			wantErr:   ErrMissingKey,
+		},
+		"no files": {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewReloader(test.giveFiles)
+			require.ErrorIs(t, err, test.wantErr)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package config implements loading of service configuration from files,
+// command-line flags and environment variables.
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envRef matches references to environment variables as ${VAR}, and escaped ones as $${VAR}.
+var envRef = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Load reads the YAML or JSON configuration file into v. Environment variables
+// referenced as ${VAR} are expanded before decoding, and unknown keys are
+// reported as errors along with their line numbers.
+func Load(path string, v any) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(ExpandEnv(content)))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ExpandEnv replaces references to environment variables as ${VAR} with their values, and $${VAR}
+// with ${VAR}. Other dollar signs, e.g. of regular expressions and password hashes, are kept as is.
+func ExpandEnv(content []byte) []byte {
+	return envRef.ReplaceAllFunc(content, func(ref []byte) []byte {
+		if escaped, ok := bytes.CutPrefix(ref, []byte("$$")); ok {
+			return append([]byte("$"), escaped...)
+		}
+
+		return []byte(os.Getenv(string(ref[2 : len(ref)-1])))
+	})
+}
+
+// ApplyEnv sets flags that were not given on the command-line from environment
+// variables named as the prefix followed by the upper-cased flag name with dashes
+// replaced by underscores, e.g. SHIPPER_RECEIVER_ADDR for -receiver-addr.
+func ApplyEnv(fs *flag.FlagSet, prefix string) error {
+	set := make(map[string]bool)
+
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var err error
+
+	fs.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] || err != nil {
+			return
+		}
+
+		name := prefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+
+		if v, ok := os.LookupEnv(name); ok {
+			if setErr := fs.Set(f.Name, v); setErr != nil {
+				err = fmt.Errorf("invalid value of %s: %w", name, setErr)
+			}
+		}
+	})
+
+	return err
+}
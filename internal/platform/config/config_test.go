@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("CONFIG_TEST_ADDR", "http://localhost:8080")
+
+	tests := map[string]struct {
+		give string
+		want string
+	}{
+		"variable": {
+			give: "url: ${CONFIG_TEST_ADDR}/v1",
+			want: "url: http://localhost:8080/v1",
+		},
+		"unset variable": {
+			give: "key: ${CONFIG_TEST_MISSING}",
+			want: "key: ",
+		},
+		"escaped variable": {
+			give: "pattern: $${CONFIG_TEST_ADDR}",
+			want: "pattern: ${CONFIG_TEST_ADDR}",
+		},
+		"regular expression": {
+			give: `pattern: '^\$5 \d+$'`,
+			want: `pattern: '^\$5 \d+$'`,
+		},
+		"password hash": {
+			give: "hash: $2a$10$abc$CONFIG_TEST_ADDR",
+			want: "hash: $2a$10$abc$CONFIG_TEST_ADDR",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.want, string(ExpandEnv([]byte(test.give))))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ErrNoConfigFile is an error when the configuration can't be reloaded as it's
+// given with command-line flags.
+var ErrNoConfigFile = errors.New("configuration is not loaded from a file")
+
+// ReloadFunc reloads the service configuration. It must keep the current configuration
+// running if the new one is invalid.
+type ReloadFunc func() error
+
+// ReloadOnSignal calls reload every time the process receives SIGHUP, until the
+// context is canceled.
+func ReloadOnSignal(ctx context.Context, reload ReloadFunc, logger *slog.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				if err := reload(); err != nil {
+					logger.Error("configuration reload has failed, keeping the current one", "error", err)
+					continue
+				}
+
+				logger.Info("configuration reloaded")
+			}
+		}
+	}()
+}
+
+// ReloadHandler returns an HTTP handler of the admin endpoint that calls reload.
+// It responds with 422 Unprocessable Entity and the error if the reload fails.
+func ReloadHandler(reload ReloadFunc, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		if err := reload(); err != nil {
+			logger.Error("configuration reload has failed, keeping the current one", "error", err)
+
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {err.Error()}})
+
+			return
+		}
+
+		logger.Info("configuration reloaded")
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "reloaded"})
+	})
+}
@@ -5,21 +5,67 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"path"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dyptan-io/log-management/v2/internal/platform/async"
 )
 
-// Watch traverses the watch directory on schedule and reads new log entries into a common buffer.
-func Watch(watchDirs []string, watchInterval time.Duration, logger *slog.Logger) *bytes.Buffer {
-	bytesRead := make(map[string]int64)
-	buffer := bytes.NewBuffer(nil)
+type (
+	// Watcher is a reader of new log entries appended to files in the watched directories.
+	Watcher struct {
+		watchDirs []string
+
+		mu        sync.Mutex
+		bytesRead map[string]int64
+		lastRead  map[string]time.Time
+		buffer    bytes.Buffer
+		// pending are chunks of files in the buffer in order, and offsets are file offsets of bytes
+		// read from the buffer, so that reading can continue right after them.
+		pending []chunk
+		offsets map[string]int64
+		closed  bool
+	}
+
+	// chunk is a number of bytes of the file in the buffer, ending at the offset.
+	chunk struct {
+		name string
+		end  int64
+		n    int64
+	}
 
-	async.Schedule(context.Background(), watchInterval, func(ctx context.Context) error {
-		for _, dir := range watchDirs {
-			if err := scanDir(dir, bytesRead, buffer); err != nil {
+	// FileStatus describes reading progress of a watched file.
+	FileStatus struct {
+		Path   string
+		Offset int64
+		// Size is the current file size, it's less than Offset if the file was truncated.
+		Size     int64
+		LastRead time.Time
+	}
+)
+
+// Watch traverses the watch directory on schedule and reads new log entries into a common
+// buffer until the context is canceled. Reading of files continues from the given offsets,
+// e.g. the ones of a previous Watcher of the same directories.
+func Watch(ctx context.Context, watchDirs []string, offsets map[string]int64, watchInterval time.Duration, logger *slog.Logger) *Watcher {
+	w := &Watcher{
+		watchDirs: watchDirs,
+		bytesRead: make(map[string]int64),
+		lastRead:  make(map[string]time.Time),
+		offsets:   make(map[string]int64),
+	}
+
+	maps.Copy(w.bytesRead, offsets)
+	maps.Copy(w.offsets, offsets)
+
+	async.Schedule(ctx, watchInterval, func(ctx context.Context) error {
+		for _, dir := range w.watchDirs {
+			if err := w.scanDir(dir); err != nil {
 				return err
 			}
 		}
@@ -27,10 +73,77 @@ func Watch(watchDirs []string, watchInterval time.Duration, logger *slog.Logger)
 		return nil
 	}, logger)
 
-	return buffer
+	return w
+}
+
+// Read reads the buffered log entries.
+func (w *Watcher) Read(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n, err := w.buffer.Read(p)
+
+	for rest := int64(n); rest > 0; {
+		c := &w.pending[0]
+
+		k := min(rest, c.n)
+		c.n -= k
+		rest -= k
+
+		w.offsets[c.name] = c.end - c.n
+
+		if c.n == 0 {
+			w.pending = w.pending[1:]
+		}
+	}
+
+	return n, err
 }
 
-func scanDir(watchDir string, bytesRead map[string]int64, w io.Writer) error {
+// Close stops reading files. Entries read from them before can still be read from the Watcher.
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+
+	return nil
+}
+
+// Offsets returns the number of bytes of every file read from the Watcher, which may be less than
+// the number of bytes read from the file while they are buffered.
+func (w *Watcher) Offsets() map[string]int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return maps.Clone(w.offsets)
+}
+
+// Files returns the status of every tracked file sorted by path.
+func (w *Watcher) Files() []FileStatus {
+	w.mu.Lock()
+
+	files := make([]FileStatus, 0, len(w.bytesRead))
+	for name, offset := range w.bytesRead {
+		files = append(files, FileStatus{Path: name, Offset: offset, LastRead: w.lastRead[name]})
+	}
+
+	w.mu.Unlock()
+
+	slices.SortFunc(files, func(a, b FileStatus) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	for i, f := range files {
+		if fi, err := os.Stat(f.Path); err == nil {
+			files[i].Size = fi.Size()
+		}
+	}
+
+	return files
+}
+
+func (w *Watcher) scanDir(watchDir string) error {
 	dir, err := os.Open(watchDir)
 	if err != nil {
 		return err
@@ -43,6 +156,13 @@ func scanDir(watchDir string, bytesRead map[string]int64, w io.Writer) error {
 		return err
 	}
 
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+
 	for _, e := range entries {
 		if e.IsDir() {
 			continue
@@ -54,40 +174,49 @@ func scanDir(watchDir string, bytesRead map[string]int64, w io.Writer) error {
 		}
 
 		name := path.Join(watchDir, e.Name())
-		size, ok := bytesRead[name]
+		size, ok := w.bytesRead[name]
 		if ok && size >= fi.Size() {
 			continue
 		}
 
-		bytesRead[name] = fi.Size()
+		n, err := readFrom(name, size, &w.buffer)
+		w.bytesRead[name] = size + n
+
+		if n > 0 {
+			w.lastRead[name] = time.Now()
+			w.pending = append(w.pending, chunk{name: name, end: size + n, n: n})
+		}
 
-		return readFrom(name, size, w)
+		// Other files are read in the same scan rather than one file per tick.
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func readFrom(filePath string, pos int64, w io.Writer) error {
+func readFrom(filePath string, pos int64, w io.Writer) (int64, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	defer file.Close()
 
 	_, err = file.Seek(pos, 0)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	b, err := io.ReadAll(file)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if _, err := w.Write(b); err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return int64(len(b)), nil
 }
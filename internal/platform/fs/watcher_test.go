@@ -0,0 +1,50 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Offsets(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+
+	require.NoError(t, os.WriteFile(a, []byte("a1\na2\n"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := Watch(ctx, []string{dir}, map[string]int64{a: 3}, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	require.Eventually(t, func() bool {
+		return w.Files()[0].Offset == 6
+	}, time.Second, 10*time.Millisecond)
+
+	// Offsets are of bytes read from the Watcher rather than from files.
+	require.Equal(t, map[string]int64{a: 3}, w.Offsets())
+
+	p := make([]byte, 2)
+
+	n, err := w.Read(p)
+	require.NoError(t, err)
+	require.Equal(t, "a2", string(p[:n]))
+	require.Equal(t, map[string]int64{a: 5}, w.Offsets())
+
+	// Files aren't read once the Watcher is closed, but read bytes are still available.
+	require.NoError(t, w.Close())
+	require.NoError(t, os.WriteFile(b, []byte("b1\n"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+
+	rest, err := io.ReadAll(w)
+	require.NoError(t, err)
+	require.Equal(t, "\n", string(rest))
+	require.Equal(t, map[string]int64{a: 6}, w.Offsets())
+	require.Len(t, w.Files(), 1)
+}
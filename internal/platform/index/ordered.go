@@ -0,0 +1,241 @@
+package index
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// chunkSize is the maximal number of keys in a chunk of Ordered index.
+const chunkSize = 512
+
+type (
+	// Key is a key of Ordered index: the time of the document, with the document number to order
+	// documents of the same time.
+	Key struct {
+		Time time.Time
+		Doc  int64
+	}
+
+	// Ordered is an index of documents ordered by their times, e.g. timestamps of Log entries. It's a two-level
+	// B+ tree: sorted chunks of sorted keys, so that finding a key costs O(log n) and ranges are read in order.
+	Ordered struct {
+		mu     sync.RWMutex
+		chunks [][]Key
+		len    int
+	}
+)
+
+// NewOrdered returns a new instance of empty Ordered index.
+func NewOrdered() *Ordered {
+	return &Ordered{}
+}
+
+// Compare returns -1, 0 or 1 if the key is less, equal or greater than the other one.
+func (k Key) Compare(other Key) int {
+	return cmp.Or(k.Time.Compare(other.Time), cmp.Compare(k.Doc, other.Doc))
+}
+
+// Add adds the key to the index, if it isn't there.
+func (o *Ordered) Add(k Key) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.chunks) == 0 {
+		o.chunks = [][]Key{{k}}
+		o.len++
+
+		return
+	}
+
+	i, j, found := o.find(k)
+	if found {
+		return
+	}
+
+	if i == len(o.chunks) {
+		// The key is greater than all keys.
+		i--
+		j = len(o.chunks[i])
+	}
+
+	chunk := slices.Insert(o.chunks[i], j, k)
+	o.len++
+
+	if len(chunk) <= chunkSize {
+		o.chunks[i] = chunk
+		return
+	}
+
+	// Full chunks are split in halves, so that keys added in order fill them up.
+	half := len(chunk) / 2
+	o.chunks[i] = slices.Clip(chunk[:half])
+	o.chunks = slices.Insert(o.chunks, i+1, slices.Clone(chunk[half:]))
+}
+
+// Remove removes the key from the index, if it's there.
+func (o *Ordered) Remove(k Key) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	i, j, found := o.find(k)
+	if !found {
+		return
+	}
+
+	o.len--
+
+	if chunk := slices.Delete(o.chunks[i], j, j+1); len(chunk) > 0 {
+		o.chunks[i] = chunk
+	} else {
+		o.chunks = slices.Delete(o.chunks, i, i+1)
+	}
+}
+
+// Len returns the number of keys.
+func (o *Ordered) Len() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.len
+}
+
+// Ascend returns keys from lo to hi inclusive in ascending order, nil bounds are unbounded.
+// Keys are read chunk by chunk, so that the index can be changed while they are iterated.
+func (o *Ordered) Ascend(lo, hi *Key) iter.Seq[Key] {
+	return func(yield func(Key) bool) {
+		var buf []Key
+
+		for from, inclusive := lo, true; ; inclusive = false {
+			buf = o.after(from, inclusive, buf[:0])
+			if len(buf) == 0 {
+				return
+			}
+
+			for _, k := range buf {
+				if hi != nil && k.Compare(*hi) > 0 || !yield(k) {
+					return
+				}
+			}
+
+			// The last key is copied, as buf is reused for the next chunk.
+			last := buf[len(buf)-1]
+			from = &last
+		}
+	}
+}
+
+// Descend returns keys from hi to lo inclusive in descending order, nil bounds are unbounded.
+// Keys are read chunk by chunk, so that the index can be changed while they are iterated.
+func (o *Ordered) Descend(hi, lo *Key) iter.Seq[Key] {
+	return func(yield func(Key) bool) {
+		var buf []Key
+
+		for from, inclusive := hi, true; ; inclusive = false {
+			buf = o.before(from, inclusive, buf[:0])
+			if len(buf) == 0 {
+				return
+			}
+
+			for _, k := range buf {
+				if lo != nil && k.Compare(*lo) < 0 || !yield(k) {
+					return
+				}
+			}
+
+			// The last key is copied, as buf is reused for the next chunk.
+			last := buf[len(buf)-1]
+			from = &last
+		}
+	}
+}
+
+// find returns the chunk that has or would have the key, the position of the key in it, and whether
+// the key is there. The chunk is len(o.chunks) if the key is greater than all keys.
+func (o *Ordered) find(k Key) (int, int, bool) {
+	i := sort.Search(len(o.chunks), func(i int) bool {
+		chunk := o.chunks[i]
+		return chunk[len(chunk)-1].Compare(k) >= 0
+	})
+
+	if i == len(o.chunks) {
+		return i, 0, false
+	}
+
+	j, found := slices.BinarySearchFunc(o.chunks[i], k, Key.Compare)
+
+	return i, j, found
+}
+
+// after appends keys after the key (or from it, if inclusive) up to the end of its chunk to buf.
+// Keys are appended from the first one if the key is nil.
+func (o *Ordered) after(k *Key, inclusive bool, buf []Key) []Key {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if k == nil {
+		if len(o.chunks) == 0 {
+			return buf
+		}
+
+		return append(buf, o.chunks[0]...)
+	}
+
+	i, j, found := o.find(*k)
+	if found && !inclusive {
+		j++
+	}
+
+	if i < len(o.chunks) && j == len(o.chunks[i]) {
+		i, j = i+1, 0
+	}
+
+	if i == len(o.chunks) {
+		return buf
+	}
+
+	return append(buf, o.chunks[i][j:]...)
+}
+
+// before appends keys before the key (or from it, if inclusive) down to the start of its chunk to buf
+// in descending order. Keys are appended from the last one if the key is nil.
+func (o *Ordered) before(k *Key, inclusive bool, buf []Key) []Key {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var chunk []Key
+
+	if k == nil {
+		if len(o.chunks) == 0 {
+			return buf
+		}
+
+		chunk = o.chunks[len(o.chunks)-1]
+	} else {
+		i, j, found := o.find(*k)
+		if found && inclusive {
+			j++
+		}
+
+		// Keys before j are less than the key, or equal to it if inclusive. They are in the previous
+		// chunk if there are none in this one, or if the key is greater than all keys.
+		if j == 0 {
+			if i == 0 {
+				return buf
+			}
+
+			i, j = i-1, len(o.chunks[i-1])
+		}
+
+		chunk = o.chunks[i][:j]
+	}
+
+	for j := len(chunk) - 1; j >= 0; j-- {
+		buf = append(buf, chunk[j])
+	}
+
+	return buf
+}
@@ -0,0 +1,107 @@
+package index
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrdered(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ordered := NewOrdered()
+
+	// Keys are added out of order, with several documents of the same time.
+	var want []Key
+
+	for _, doc := range rand.New(rand.NewPCG(1, 2)).Perm(5 * chunkSize) {
+		k := Key{Time: start.Add(time.Duration(doc/3) * time.Second), Doc: int64(doc)}
+
+		ordered.Add(k)
+		ordered.Add(k)
+
+		want = append(want, k)
+	}
+
+	slices.SortFunc(want, Key.Compare)
+
+	require.Equal(t, len(want), ordered.Len())
+
+	key := func(i int) *Key { return &want[i] }
+
+	tests := map[string]struct {
+		giveLo   *Key
+		giveHi   *Key
+		wantKeys []Key
+	}{
+		"all keys": {
+			wantKeys: want,
+		},
+		"from key": {
+			giveLo:   key(chunkSize),
+			wantKeys: want[chunkSize:],
+		},
+		"to key": {
+			giveHi:   key(3 * chunkSize),
+			wantKeys: want[:3*chunkSize+1],
+		},
+		"range": {
+			giveLo:   key(100),
+			giveHi:   key(2000),
+			wantKeys: want[100:2001],
+		},
+		"bounds between keys": {
+			giveLo:   &Key{Time: want[10].Time, Doc: want[10].Doc + 1},
+			giveHi:   &Key{Time: want[20].Time, Doc: want[20].Doc - 1},
+			wantKeys: want[11:20],
+		},
+		"before all keys": {
+			giveHi: &Key{Time: start.Add(-time.Second)},
+		},
+		"after all keys": {
+			giveLo: &Key{Time: start.Add(time.Hour)},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.wantKeys, slices.Collect(ordered.Ascend(test.giveLo, test.giveHi)))
+
+			descending := slices.Clone(test.wantKeys)
+			slices.Reverse(descending)
+
+			require.Equal(t, descending, slices.Collect(ordered.Descend(test.giveHi, test.giveLo)))
+		})
+	}
+}
+
+func TestOrdered_Remove(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ordered := NewOrdered()
+
+	for doc := range 3 * chunkSize {
+		ordered.Add(Key{Time: start.Add(time.Duration(doc) * time.Second), Doc: int64(doc)})
+	}
+
+	// Removing keys while iterating doesn't skip the remaining ones.
+	var got []int64
+
+	for k := range ordered.Ascend(nil, nil) {
+		got = append(got, k.Doc)
+
+		ordered.Remove(k)
+	}
+
+	require.Len(t, got, 3*chunkSize)
+	require.Zero(t, ordered.Len())
+	require.Empty(t, slices.Collect(ordered.Descend(nil, nil)))
+
+	ordered.Remove(Key{Time: start})
+	ordered.Add(Key{Time: start, Doc: 1})
+
+	require.Equal(t, []Key{{Time: start, Doc: 1}}, slices.Collect(ordered.Descend(nil, nil)))
+}
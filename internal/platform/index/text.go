@@ -0,0 +1,383 @@
+// Package index implements in-memory indexes of documents identified by int64 numbers, e.g.
+// sequence numbers of records, that are kept up to date as documents are added and removed.
+package index
+
+import (
+	"container/heap"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// minCompaction is the minimal number of removed documents before posting lists are compacted.
+const minCompaction = 1024
+
+type (
+	// Text is an inverted index of terms of documents, e.g. messages of Log entries. Terms are lowercase
+	// sequences of letters and digits. Posting lists are sorted, so that they are intersected by merging.
+	Text struct {
+		mu       sync.RWMutex
+		postings map[string][]int64
+		// docs are terms of documents in order, to remove documents and to verify phrases.
+		docs map[int64][]string
+		// stale is the number of removed documents still in posting lists.
+		stale int
+		// terms are sorted terms of the postings for prefix searches, kept up to date as terms are
+		// added and removed, so that searches don't sort them.
+		terms []string
+	}
+
+	// TextQuery is a query of documents containing all of its terms, phrases and terms with prefixes.
+	TextQuery struct {
+		Terms    []string
+		Phrases  [][]string
+		Prefixes []string
+	}
+)
+
+// NewText returns a new instance of empty Text index.
+func NewText() *Text {
+	return &Text{postings: make(map[string][]int64), docs: make(map[int64][]string)}
+}
+
+// Tokenize returns lowercase terms of the text in order.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ParseTextQuery returns the query of space-separated words, e.g. `payment "connection reset" time*`.
+// Quoted words and words of several terms like "user-42" are phrases, and words ending with "*"
+// are prefixes of terms.
+func ParseTextQuery(s string) TextQuery {
+	var q TextQuery
+
+	for i, part := range strings.Split(s, `"`) {
+		// Odd parts are quoted.
+		if i%2 == 1 {
+			q.add(Tokenize(part))
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			prefix, isPrefix := strings.CutSuffix(word, "*")
+			terms := Tokenize(prefix)
+
+			if isPrefix && len(terms) > 0 && strings.HasSuffix(strings.ToLower(prefix), terms[len(terms)-1]) {
+				q.Prefixes = append(q.Prefixes, terms[len(terms)-1])
+				terms = terms[:len(terms)-1]
+			}
+
+			q.add(terms)
+		}
+	}
+
+	return q
+}
+
+// IsZero reports whether the query has nothing to search for, e.g. it's empty or has no letters and digits.
+func (q TextQuery) IsZero() bool {
+	return len(q.Terms) == 0 && len(q.Phrases) == 0 && len(q.Prefixes) == 0
+}
+
+// Match reports whether the text matches the query, without an index.
+func (q TextQuery) Match(text string) bool {
+	return q.matchTerms(Tokenize(text))
+}
+
+// matchTerms reports whether terms of a text match the query.
+func (q TextQuery) matchTerms(terms []string) bool {
+	for _, term := range q.Terms {
+		if !slices.Contains(terms, term) {
+			return false
+		}
+	}
+
+	for _, phrase := range q.Phrases {
+		if !containsPhrase(terms, phrase) {
+			return false
+		}
+	}
+
+	for _, prefix := range q.Prefixes {
+		if !slices.ContainsFunc(terms, func(term string) bool { return strings.HasPrefix(term, prefix) }) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// add adds terms of a word or a quoted string to the query.
+func (q *TextQuery) add(terms []string) {
+	switch len(terms) {
+	case 0:
+	case 1:
+		q.Terms = append(q.Terms, terms[0])
+	default:
+		q.Phrases = append(q.Phrases, terms)
+	}
+}
+
+// Add indexes the text of the document, replacing the indexed one if any.
+func (t *Text) Add(doc int64, text string) {
+	terms := Tokenize(text)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Posting lists of replaced terms are updated right away, as the document isn't removed.
+	for _, term := range t.docs[doc] {
+		if !slices.Contains(terms, term) {
+			t.unpost(doc, term)
+		}
+	}
+
+	t.docs[doc] = terms
+
+	for i, term := range terms {
+		if slices.Contains(terms[:i], term) {
+			continue
+		}
+
+		list, ok := t.postings[term]
+		if !ok {
+			i, _ := slices.BinarySearch(t.terms, term)
+			t.terms = slices.Insert(t.terms, i, term)
+		}
+
+		// Documents are mostly added in order, otherwise they are inserted in place.
+		if n := len(list); n == 0 || list[n-1] < doc {
+			t.postings[term] = append(list, doc)
+		} else if j, found := slices.BinarySearch(list, doc); !found {
+			t.postings[term] = slices.Insert(list, j, doc)
+		}
+	}
+}
+
+// Remove removes the document from the index.
+func (t *Text) Remove(doc int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.remove(doc)
+}
+
+// Len returns the number of indexed documents.
+func (t *Text) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return len(t.docs)
+}
+
+// Search returns sorted documents matching the query. It returns nil for the zero query.
+func (t *Text) Search(q TextQuery) []int64 {
+	if q.IsZero() {
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	lists := make([][]int64, 0, len(q.Terms)+len(q.Phrases)+len(q.Prefixes))
+
+	for _, term := range q.Terms {
+		lists = append(lists, t.postings[term])
+	}
+
+	for _, phrase := range q.Phrases {
+		for _, term := range phrase {
+			lists = append(lists, t.postings[term])
+		}
+	}
+
+	for _, prefix := range q.Prefixes {
+		lists = append(lists, t.prefixed(prefix))
+	}
+
+	// Intersecting the shortest lists first keeps intermediate results small.
+	slices.SortFunc(lists, func(a, b []int64) int { return len(a) - len(b) })
+
+	docs := lists[0]
+	for _, list := range lists[1:] {
+		if len(docs) == 0 {
+			break
+		}
+
+		docs = intersect(docs, list)
+	}
+
+	res := make([]int64, 0, len(docs))
+
+	for _, doc := range docs {
+		terms, ok := t.docs[doc]
+		if !ok {
+			continue
+		}
+
+		// Documents are verified, as posting lists don't keep positions of terms for phrases,
+		// and may keep documents that have been removed and added again.
+		if q.matchTerms(terms) {
+			res = append(res, doc)
+		}
+	}
+
+	return res
+}
+
+// remove removes the document, compacting posting lists if most of their documents are removed.
+func (t *Text) remove(doc int64) {
+	if _, ok := t.docs[doc]; !ok {
+		return
+	}
+
+	delete(t.docs, doc)
+
+	if t.stale++; t.stale >= minCompaction && t.stale > len(t.docs) {
+		t.compact()
+	}
+}
+
+// unpost removes the document from the posting list of the term.
+func (t *Text) unpost(doc int64, term string) {
+	list := t.postings[term]
+
+	if i, found := slices.BinarySearch(list, doc); found {
+		if list = slices.Delete(list, i, i+1); len(list) == 0 {
+			delete(t.postings, term)
+
+			if j, found := slices.BinarySearch(t.terms, term); found {
+				t.terms = slices.Delete(t.terms, j, j+1)
+			}
+
+			return
+		}
+
+		t.postings[term] = list
+	}
+}
+
+// compact removes removed documents from posting lists, and terms without documents.
+func (t *Text) compact() {
+	for term, list := range t.postings {
+		list = slices.DeleteFunc(list, func(doc int64) bool {
+			_, ok := t.docs[doc]
+			return !ok
+		})
+
+		if len(list) == 0 {
+			delete(t.postings, term)
+
+			continue
+		}
+
+		t.postings[term] = slices.Clip(list)
+	}
+
+	t.terms = slices.DeleteFunc(t.terms, func(term string) bool {
+		_, ok := t.postings[term]
+		return !ok
+	})
+	t.stale = 0
+}
+
+// prefixed returns the sorted union of posting lists of terms with the prefix.
+func (t *Text) prefixed(prefix string) []int64 {
+	var lists [][]int64
+
+	add := func(term string) {
+		if list := t.postings[term]; len(list) > 0 {
+			lists = append(lists, list)
+		}
+	}
+
+	i, _ := slices.BinarySearch(t.terms, prefix)
+	for ; i < len(t.terms) && strings.HasPrefix(t.terms[i], prefix); i++ {
+		add(t.terms[i])
+	}
+
+	return union(lists)
+}
+
+// intersect returns documents of both sorted lists.
+func intersect(a, b []int64) []int64 {
+	res := make([]int64, 0, min(len(a), len(b)))
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			res = append(res, a[i])
+			i++
+			j++
+		}
+	}
+
+	return res
+}
+
+// union returns documents of any of sorted lists, merging them at once rather than pairwise,
+// as prefixes of short terms may have many lists.
+func union(lists [][]int64) []int64 {
+	var n int
+
+	h := make(cursors, 0, len(lists))
+
+	for _, list := range lists {
+		if len(list) > 0 {
+			h = append(h, list)
+			n += len(list)
+		}
+	}
+
+	heap.Init(&h)
+
+	res := make([]int64, 0, n)
+
+	for len(h) > 0 {
+		if doc := h[0][0]; len(res) == 0 || res[len(res)-1] != doc {
+			res = append(res, doc)
+		}
+
+		if h[0] = h[0][1:]; len(h[0]) > 0 {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+
+	return res
+}
+
+// cursors are remainders of sorted lists, a heap by their first documents.
+type cursors [][]int64
+
+func (c cursors) Len() int           { return len(c) }
+func (c cursors) Less(i, j int) bool { return c[i][0] < c[j][0] }
+func (c cursors) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c *cursors) Push(x any)        { *c = append(*c, x.([]int64)) }
+
+func (c *cursors) Pop() any {
+	old := *c
+	x := old[len(old)-1]
+	*c = old[:len(old)-1]
+
+	return x
+}
+
+// containsPhrase reports whether terms contain the phrase as consecutive terms.
+func containsPhrase(terms, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(terms); i++ {
+		if slices.Equal(terms[i:i+len(phrase)], phrase) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,148 @@
+package index
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTextQuery(t *testing.T) {
+	tests := map[string]struct {
+		give string
+		want TextQuery
+	}{
+		"terms": {
+			give: "Payment  TIMEOUT",
+			want: TextQuery{Terms: []string{"payment", "timeout"}},
+		},
+		"phrases": {
+			give: `"connection reset" user-42`,
+			want: TextQuery{Phrases: [][]string{{"connection", "reset"}, {"user", "42"}}},
+		},
+		"prefixes": {
+			give: "time* user-4* -*",
+			want: TextQuery{Terms: []string{"user"}, Prefixes: []string{"time", "4"}},
+		},
+		"quoted term": {
+			give: `"timeout"`,
+			want: TextQuery{Terms: []string{"timeout"}},
+		},
+		"no terms": {
+			give: `- "" *`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.want, ParseTextQuery(test.give))
+		})
+	}
+}
+
+func TestText_Search(t *testing.T) {
+	text := NewText()
+
+	docs := []string{
+		"Payment timeout after 30s",
+		"connection reset by peer",
+		"payment accepted",
+		"timed out waiting for connection",
+		"reset connection pool",
+	}
+
+	for i, doc := range docs {
+		text.Add(int64(i+1), doc)
+	}
+
+	tests := map[string]struct {
+		give string
+		want []int64
+	}{
+		"term": {
+			give: "payment",
+			want: []int64{1, 3},
+		},
+		"terms": {
+			give: "connection reset",
+			want: []int64{2, 5},
+		},
+		"phrase": {
+			give: `"connection reset"`,
+			want: []int64{2},
+		},
+		"prefix": {
+			give: "time*",
+			want: []int64{1, 4},
+		},
+		"prefix of several terms": {
+			give: "p*",
+			want: []int64{1, 2, 3, 5},
+		},
+		"term and prefix": {
+			give: "payment time*",
+			want: []int64{1},
+		},
+		"unknown term": {
+			give: "payment refused",
+			want: []int64{},
+		},
+		"zero query": {
+			give: "*",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			q := ParseTextQuery(test.give)
+
+			got := text.Search(q)
+			require.Equal(t, test.want, got)
+
+			for _, doc := range got {
+				require.True(t, q.Match(docs[doc-1]))
+			}
+		})
+	}
+}
+
+func TestText_Remove(t *testing.T) {
+	text := NewText()
+
+	for i := range 3 * minCompaction {
+		text.Add(int64(i), fmt.Sprintf("entry %d of batch %d", i, i%2))
+	}
+
+	q := ParseTextQuery("batch 0")
+	require.Len(t, text.Search(q), 3*minCompaction/2)
+
+	// Removing most documents compacts posting lists.
+	for i := range 2 * minCompaction {
+		text.Remove(int64(i))
+	}
+
+	require.Equal(t, minCompaction, text.Len())
+	require.Len(t, text.Search(q), minCompaction/2)
+	require.Empty(t, text.Search(ParseTextQuery(`"entry 1 of"`)))
+	require.Equal(t, []int64{2 * minCompaction}, text.Search(ParseTextQuery(fmt.Sprint(2*minCompaction))))
+
+	// Replaced and re-added documents match their current text only.
+	text.Add(2*minCompaction, "replaced")
+	text.Add(1, "added again")
+
+	require.Empty(t, text.Search(ParseTextQuery(fmt.Sprint(2*minCompaction))))
+	require.Equal(t, []int64{1}, text.Search(ParseTextQuery("again")))
+	require.Empty(t, text.Search(ParseTextQuery(`"entry 1 of"`)))
+
+	// Sorted terms of prefix searches are kept in line with posting lists.
+	require.True(t, slices.IsSorted(text.terms))
+	require.ElementsMatch(t, slices.Collect(maps.Keys(text.postings)), text.terms)
+	require.Equal(t, []int64{2 * minCompaction}, text.Search(ParseTextQuery("repl*")))
+	require.Empty(t, text.Search(ParseTextQuery(fmt.Sprint(2*minCompaction)+"*")))
+}
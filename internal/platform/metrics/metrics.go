@@ -0,0 +1,336 @@
+// Package metrics implements counters and gauges exposed in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// labelEscaper escapes label values as required by the exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type (
+	// Counter is a monotonically increasing metric. Updates of a nil Counter are ignored,
+	// so that instrumented code doesn't have to check whether metrics are enabled.
+	Counter struct {
+		v atomic.Uint64
+	}
+
+	// Gauge is a metric that can go up and down. Updates of a nil Gauge are ignored.
+	Gauge struct {
+		v atomic.Int64
+	}
+
+	// Histogram counts observed values in buckets. Observations of a nil Histogram are ignored.
+	Histogram struct {
+		mu      sync.Mutex
+		buckets []float64
+		counts  []uint64
+		count   uint64
+		sum     float64
+	}
+
+	// CounterVec is a family of counters partitioned by label values.
+	CounterVec struct {
+		*family
+	}
+
+	// GaugeVec is a family of gauges partitioned by label values.
+	GaugeVec struct {
+		*family
+	}
+
+	// HistogramVec is a family of histograms partitioned by label values.
+	HistogramVec struct {
+		*family
+	}
+
+	// Registry is a set of metric families exposed together.
+	Registry struct {
+		mu       sync.Mutex
+		families []*family
+	}
+
+	family struct {
+		name   string
+		help   string
+		kind   string
+		labels []string
+		// buckets are upper bounds of histogram buckets.
+		buckets []float64
+		// value reports the value of a gauge computed at collection time.
+		value func() int64
+
+		mu     sync.Mutex
+		series map[string]*series
+	}
+
+	series struct {
+		labels    []string
+		counter   *Counter
+		gauge     *Gauge
+		histogram *Histogram
+	}
+)
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increments the counter by n.
+func (c *Counter) Add(n uint64) {
+	if c != nil {
+		c.v.Add(n)
+	}
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() uint64 {
+	if c == nil {
+		return 0
+	}
+
+	return c.v.Load()
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v int64) {
+	if g != nil {
+		g.v.Store(v)
+	}
+}
+
+// Add adds delta, which may be negative, to the gauge.
+func (g *Gauge) Add(delta int64) {
+	if g != nil {
+		g.v.Add(delta)
+	}
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() int64 {
+	if g == nil {
+		return 0
+	}
+
+	return g.v.Load()
+}
+
+// Observe adds the value to the histogram.
+func (h *Histogram) Observe(v float64) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += v
+}
+
+// NewRegistry returns a new instance of Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounterVec registers a new counter family with the given label names.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) CounterVec {
+	return CounterVec{r.register(name, help, "counter", labels)}
+}
+
+// NewGaugeVec registers a new gauge family with the given label names.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) GaugeVec {
+	return GaugeVec{r.register(name, help, "gauge", labels)}
+}
+
+// NewHistogramVec registers a new histogram family with the given bucket upper bounds,
+// which must be sorted in increasing order, and label names.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) HistogramVec {
+	f := r.register(name, help, "histogram", labels)
+	f.buckets = slices.Clone(buckets)
+
+	return HistogramVec{f}
+}
+
+// NewGaugeFunc registers a gauge without labels whose value is reported by the function
+// every time metrics are collected, e.g. the size of a data structure.
+func (r *Registry) NewGaugeFunc(name, help string, value func() int64) {
+	r.register(name, help, "gauge", nil).value = value
+}
+
+// With returns the counter of the given label values, creating it if needed.
+func (v CounterVec) With(values ...string) *Counter {
+	return v.get(values).counter
+}
+
+// With returns the gauge of the given label values, creating it if needed.
+func (v GaugeVec) With(values ...string) *Gauge {
+	return v.get(values).gauge
+}
+
+// With returns the histogram of the given label values, creating it if needed.
+func (v HistogramVec) With(values ...string) *Histogram {
+	return v.get(values).histogram
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	var sb strings.Builder
+
+	for _, f := range families {
+		f.write(&sb)
+	}
+
+	n, err := io.WriteString(w, sb.String())
+
+	return int64(n), err
+}
+
+// ServeHTTP serves metrics to Prometheus scrapers.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+func (r *Registry) register(name, help, kind string, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, f := range r.families {
+		if f.name == name {
+			panic(fmt.Sprintf("metrics: %q is already registered", name))
+		}
+	}
+
+	f := &family{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		series: make(map[string]*series),
+	}
+
+	r.families = append(r.families, f)
+
+	return f
+}
+
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %q expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: slices.Clone(values)}
+
+		switch f.kind {
+		case "counter":
+			s.counter = &Counter{}
+		case "gauge":
+			s.gauge = &Gauge{}
+		case "histogram":
+			s.histogram = &Histogram{buckets: f.buckets, counts: make([]uint64, len(f.buckets))}
+		}
+
+		f.series[key] = s
+	}
+
+	return s
+}
+
+func (f *family) write(sb *strings.Builder) {
+	fmt.Fprintf(sb, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(sb, "# TYPE %s %s\n", f.name, f.kind)
+
+	if f.value != nil {
+		fmt.Fprintf(sb, "%s %d\n", f.name, f.value())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		s := f.series[k]
+		labels := f.formatLabels(s.labels)
+
+		switch f.kind {
+		case "counter":
+			fmt.Fprintf(sb, "%s%s %d\n", f.name, labels(), s.counter.Value())
+		case "gauge":
+			fmt.Fprintf(sb, "%s%s %d\n", f.name, labels(), s.gauge.Value())
+		case "histogram":
+			s.histogram.write(sb, f.name, labels)
+		}
+	}
+}
+
+// formatLabels returns a function formatting label pairs with optional extra ones,
+// e.g. the upper bound of a histogram bucket.
+func (f *family) formatLabels(values []string) func(extra ...string) string {
+	return func(extra ...string) string {
+		pairs := make([]string, 0, len(f.labels)+len(extra)/2)
+
+		for i, l := range f.labels {
+			pairs = append(pairs, l+`="`+labelEscaper.Replace(values[i])+`"`)
+		}
+
+		for i := 0; i+1 < len(extra); i += 2 {
+			pairs = append(pairs, extra[i]+`="`+labelEscaper.Replace(extra[i+1])+`"`)
+		}
+
+		if len(pairs) == 0 {
+			return ""
+		}
+
+		return "{" + strings.Join(pairs, ",") + "}"
+	}
+}
+
+func (h *Histogram) write(sb *strings.Builder, name string, labels func(extra ...string) string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, b := range h.buckets {
+		fmt.Fprintf(sb, "%s_bucket%s %d\n", name, labels("le", formatFloat(b)), h.counts[i])
+	}
+
+	fmt.Fprintf(sb, "%s_bucket%s %d\n", name, labels("le", "+Inf"), h.count)
+	fmt.Fprintf(sb, "%s_sum%s %s\n", name, labels(), formatFloat(h.sum))
+	fmt.Fprintf(sb, "%s_count%s %d\n", name, labels(), h.count)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
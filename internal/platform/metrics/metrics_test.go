@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry()
+
+	sent := r.NewCounterVec("entries_sent_total", "Entries sent.", "source", "output")
+	sent.With("app", "http://b").Add(3)
+	sent.With("app", "http://a").Inc()
+	sent.With("app", "http://a").Inc()
+
+	pending := r.NewGaugeVec("entries_pending", "Entries waiting to be sent.", "source")
+	pending.With(`we"ird`).Add(5)
+	pending.With(`we"ird`).Add(-2)
+
+	var sb strings.Builder
+
+	_, err := r.WriteTo(&sb)
+	require.NoError(t, err)
+	require.Equal(t, `# HELP entries_sent_total Entries sent.
+# TYPE entries_sent_total counter
+entries_sent_total{source="app",output="http://a"} 2
+entries_sent_total{source="app",output="http://b"} 3
+# HELP entries_pending Entries waiting to be sent.
+# TYPE entries_pending gauge
+entries_pending{source="we\"ird"} 3
+`, sb.String())
+}
+
+func TestRegistry_WriteTo_Histogram(t *testing.T) {
+	r := NewRegistry()
+
+	latency := r.NewHistogramVec("request_duration_seconds", "Request latency.", []float64{0.1, 0.5}, "operation")
+	latency.With("PostLog").Observe(0.05)
+	latency.With("PostLog").Observe(0.3)
+	latency.With("PostLog").Observe(2)
+
+	r.NewGaugeFunc("entries_stored", "Stored entries.", func() int64 { return 42 })
+
+	var sb strings.Builder
+
+	_, err := r.WriteTo(&sb)
+	require.NoError(t, err)
+	require.Equal(t, `# HELP request_duration_seconds Request latency.
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{operation="PostLog",le="0.1"} 1
+request_duration_seconds_bucket{operation="PostLog",le="0.5"} 2
+request_duration_seconds_bucket{operation="PostLog",le="+Inf"} 3
+request_duration_seconds_sum{operation="PostLog"} 2.35
+request_duration_seconds_count{operation="PostLog"} 3
+# HELP entries_stored Stored entries.
+# TYPE entries_stored gauge
+entries_stored 42
+`, sb.String())
+}
+
+func TestNilMetrics(t *testing.T) {
+	var (
+		c *Counter
+		g *Gauge
+		h *Histogram
+	)
+
+	c.Inc()
+	g.Add(1)
+	h.Observe(1)
+
+	require.Zero(t, c.Value())
+	require.Zero(t, g.Value())
+}
+
+func TestRegistry_Register(t *testing.T) {
+	tests := map[string]struct {
+		give func(r *Registry)
+	}{
+		"duplicate name": {
+			give: func(r *Registry) {
+				r.NewCounterVec("x", "")
+				r.NewGaugeVec("x", "")
+			},
+		},
+		"wrong label values": {
+			give: func(r *Registry) {
+				r.NewCounterVec("x", "", "a").With("1", "2")
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Panics(t, func() { tt.give(NewRegistry()) })
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Package query implements the parser of a small boolean query language, e.g.
+//
+//	severity>=warn AND (service:payments OR message~"timeout") AND NOT attributes.env:dev
+//
+// Queries are comparisons of fields to values, combined with AND, OR, NOT and parentheses. AND binds
+// tighter than OR, and keywords are case-insensitive. Fields are names of letters, digits, underscores,
+// dashes and dots. Values are words up to a space or a parenthesis, or double-quoted strings with Go escapes.
+// Meaning of fields, operators and values is up to the compiler of the parsed Expr.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Comparison operators.
+const (
+	Match          Op = ":"
+	Equal          Op = "="
+	NotEqual       Op = "!="
+	Regexp         Op = "~"
+	Less           Op = "<"
+	LessOrEqual    Op = "<="
+	Greater        Op = ">"
+	GreaterOrEqual Op = ">="
+)
+
+type (
+	// Op is a comparison operator.
+	Op string
+
+	// Expr is a parsed query expression: And, Or, Not or Comparison.
+	Expr interface {
+		// Pos returns the byte offset of the expression in the query.
+		Pos() int
+	}
+
+	// And matches if both expressions match.
+	And struct {
+		Left, Right Expr
+	}
+
+	// Or matches if any of expressions matches.
+	Or struct {
+		Left, Right Expr
+	}
+
+	// Not matches if the expression doesn't match.
+	Not struct {
+		Expr   Expr
+		NotPos int
+	}
+
+	// Comparison compares the field to the value.
+	Comparison struct {
+		Field    string
+		FieldPos int
+		Op       Op
+		Value    string
+		ValuePos int
+		// Quoted tells whether the value is a quoted string, e.g. to tell "*" from a wildcard.
+		Quoted bool
+	}
+
+	// Error is an error of the query at the byte offset.
+	Error struct {
+		Pos int
+		Msg string
+	}
+
+	// parser is a recursive descent parser of queries.
+	parser struct {
+		s   string
+		pos int
+	}
+)
+
+// ops are operators ordered so that longer ones are tried first.
+var ops = []Op{NotEqual, LessOrEqual, GreaterOrEqual, Match, Equal, Regexp, Less, Greater}
+
+// Parse returns the expression of the query.
+func Parse(s string) (Expr, error) {
+	p := &parser{s: s}
+
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.skipSpace(); p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %s, expected AND, OR or the end of the query", p.token())
+	}
+
+	return expr, nil
+}
+
+// Errorf returns an Error at the byte offset.
+func Errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Error returns the message with the 1-based column of the error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos+1, e.Msg)
+}
+
+func (e And) Pos() int        { return e.Left.Pos() }
+func (e Or) Pos() int         { return e.Left.Pos() }
+func (e Not) Pos() int        { return e.NotPos }
+func (e Comparison) Pos() int { return e.FieldPos }
+
+// or parses: and { OR and }.
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+
+		left = Or{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// and parses: not { AND not }.
+func (p *parser) and() (Expr, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("AND") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+
+		left = And{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// not parses: NOT not | "(" or ")" | comparison.
+func (p *parser) not() (Expr, error) {
+	p.skipSpace()
+	start := p.pos
+
+	if p.keyword("NOT") {
+		expr, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+
+		return Not{Expr: expr, NotPos: start}, nil
+	}
+
+	if p.pos < len(p.s) && p.s[p.pos] == '(' {
+		p.pos++
+
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.skipSpace(); p.pos >= len(p.s) || p.s[p.pos] != ')' {
+			return nil, p.errorf("unexpected %s, expected \")\" closing \"(\" at column %d", p.token(), start+1)
+		}
+
+		p.pos++
+
+		return expr, nil
+	}
+
+	return p.comparison()
+}
+
+// comparison parses: field op value.
+func (p *parser) comparison() (Expr, error) {
+	c := Comparison{FieldPos: p.pos}
+
+	for p.pos < len(p.s) && isFieldChar(rune(p.s[p.pos])) {
+		p.pos++
+	}
+
+	c.Field = p.s[c.FieldPos:p.pos]
+	if c.Field == "" {
+		return nil, p.errorf("unexpected %s, expected a field", p.token())
+	}
+
+	for _, op := range ops {
+		if strings.HasPrefix(p.s[p.pos:], string(op)) {
+			c.Op = op
+			break
+		}
+	}
+
+	if c.Op == "" {
+		return nil, p.errorf("unexpected %s, expected an operator after %q", p.token(), c.Field)
+	}
+
+	p.pos += len(c.Op)
+	c.ValuePos = p.pos
+
+	if p.pos < len(p.s) && p.s[p.pos] == '"' {
+		value, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+
+		c.Value, c.Quoted = value, true
+
+		return c, nil
+	}
+
+	for p.pos < len(p.s) && !unicode.IsSpace(rune(p.s[p.pos])) && p.s[p.pos] != '(' && p.s[p.pos] != ')' {
+		p.pos++
+	}
+
+	c.Value = p.s[c.ValuePos:p.pos]
+	if c.Value == "" {
+		return nil, p.errorf("unexpected %s, expected a value after %q", p.token(), c.Field+string(c.Op))
+	}
+
+	return c, nil
+}
+
+// quoted parses a double-quoted string.
+func (p *parser) quoted() (string, error) {
+	start := p.pos
+
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		switch p.s[p.pos] {
+		case '\\':
+			p.pos++
+		case '"':
+			p.pos++
+
+			value, err := strconv.Unquote(p.s[start:p.pos])
+			if err != nil {
+				return "", Errorf(start, "malformed string %s", p.s[start:p.pos])
+			}
+
+			return value, nil
+		}
+	}
+
+	return "", Errorf(start, "unterminated string")
+}
+
+// keyword reports whether the next word is the keyword, case-insensitively, and skips it if so.
+func (p *parser) keyword(kw string) bool {
+	p.skipSpace()
+
+	end := p.pos + len(kw)
+	if end > len(p.s) || !strings.EqualFold(p.s[p.pos:end], kw) {
+		return false
+	}
+
+	// Keywords are followed by a space, a parenthesis or a quote, so that fields may start with them.
+	if end < len(p.s) && (isFieldChar(rune(p.s[end])) || strings.HasPrefix(p.s[end:], string(NotEqual)) ||
+		strings.ContainsRune(":=~<>", rune(p.s[end]))) {
+		return false
+	}
+
+	p.pos = end
+
+	return true
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// token returns a description of the text at the current position for error messages.
+func (p *parser) token() string {
+	if p.pos >= len(p.s) {
+		return "end of the query"
+	}
+
+	end := p.pos + 1
+	for end < len(p.s) && isFieldChar(rune(p.s[end-1])) && isFieldChar(rune(p.s[end])) {
+		end++
+	}
+
+	return strconv.Quote(p.s[p.pos:end])
+}
+
+func (p *parser) errorf(format string, args ...any) *Error {
+	return Errorf(p.pos, format, args...)
+}
+
+func isFieldChar(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.')
+}
@@ -0,0 +1,115 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		give     string
+		wantExpr Expr
+	}{
+		"comparison": {
+			give:     "severity>=warn",
+			wantExpr: Comparison{Field: "severity", Op: GreaterOrEqual, Value: "warn", ValuePos: 10},
+		},
+		"quoted value": {
+			give:     `message~"time\"out"`,
+			wantExpr: Comparison{Field: "message", Op: Regexp, Value: `time"out`, ValuePos: 8, Quoted: true},
+		},
+		"value with colons": {
+			give:     "timestamp<2024-01-02T00:00:00Z",
+			wantExpr: Comparison{Field: "timestamp", Op: Less, Value: "2024-01-02T00:00:00Z", ValuePos: 10},
+		},
+		"AND binds tighter than OR": {
+			give: "a:1 OR b:2 and c:3",
+			wantExpr: Or{
+				Left: Comparison{Field: "a", Op: Match, Value: "1", ValuePos: 2},
+				Right: And{
+					Left:  Comparison{Field: "b", FieldPos: 7, Op: Match, Value: "2", ValuePos: 9},
+					Right: Comparison{Field: "c", FieldPos: 15, Op: Match, Value: "3", ValuePos: 17},
+				},
+			},
+		},
+		"parentheses and NOT": {
+			give: "NOT (a!=1 OR b:2)",
+			wantExpr: Not{Expr: Or{
+				Left:  Comparison{Field: "a", FieldPos: 5, Op: NotEqual, Value: "1", ValuePos: 8},
+				Right: Comparison{Field: "b", FieldPos: 13, Op: Match, Value: "2", ValuePos: 15},
+			}},
+		},
+		"fields starting with keywords": {
+			give: "note:1 AND order:2",
+			wantExpr: And{
+				Left:  Comparison{Field: "note", Op: Match, Value: "1", ValuePos: 5},
+				Right: Comparison{Field: "order", FieldPos: 11, Op: Match, Value: "2", ValuePos: 17},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(test.give)
+
+			require.NoError(t, err)
+			require.Equal(t, test.wantExpr, got)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]struct {
+		give    string
+		wantErr string
+	}{
+		"empty query": {
+			give:    "",
+			wantErr: "column 1: unexpected end of the query, expected a field",
+		},
+		"missing operator": {
+			give:    "severity warn",
+			wantErr: `column 9: unexpected " ", expected an operator after "severity"`,
+		},
+		"missing value": {
+			give:    "severity>= AND a:1",
+			wantErr: `column 11: unexpected " ", expected a value after "severity>="`,
+		},
+		"missing operand": {
+			give:    "a:1 AND",
+			wantErr: "column 8: unexpected end of the query, expected a field",
+		},
+		"unclosed parenthesis": {
+			give:    "(a:1 OR b:2",
+			wantErr: `column 12: unexpected end of the query, expected ")" closing "(" at column 1`,
+		},
+		"missing keyword": {
+			give:    "a:1 b:2",
+			wantErr: `column 5: unexpected "b", expected AND, OR or the end of the query`,
+		},
+		"unterminated string": {
+			give:    `message:"timeout`,
+			wantErr: "column 9: unterminated string",
+		},
+		"malformed string": {
+			give:    `message:"\q"`,
+			wantErr: `column 9: malformed string "\q"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse(test.give)
+
+			var queryErr *Error
+
+			require.ErrorAs(t, err, &queryErr)
+			require.EqualError(t, err, test.wantErr)
+		})
+	}
+}
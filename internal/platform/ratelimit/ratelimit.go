@@ -0,0 +1,271 @@
+// Package ratelimit implements token bucket rate limiters and fixed window quotas.
+package ratelimit
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+type (
+	// Bucket is a token bucket that refills at a constant rate up to its burst size.
+	Bucket struct {
+		mu     sync.Mutex
+		rate   float64
+		burst  float64
+		tokens float64
+		last   time.Time
+	}
+
+	// Limiter keeps a separate token bucket for every key, e.g. a source or a client.
+	Limiter struct {
+		mu      sync.Mutex
+		rate    float64
+		burst   int
+		buckets map[string]*Bucket
+		now     func() time.Time
+	}
+
+	// Quota limits the total amount, e.g. of bytes, per key within fixed time windows.
+	// Windows are aligned to multiples of their duration since the zero time, so daily
+	// windows start at midnight UTC.
+	Quota struct {
+		mu     sync.Mutex
+		limit  int64
+		window time.Duration
+		start  time.Time
+		used   map[string]int64
+		now    func() time.Time
+	}
+)
+
+// NewBucket returns a new full Bucket that refills rate tokens per second.
+func NewBucket(rate float64, burst int) *Bucket {
+	return &Bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// AllowN takes n tokens from the bucket. If there are not enough tokens, it returns false
+// and the duration after which n tokens will be available.
+func (b *Bucket) AllowN(now time.Time, n int) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+
+	if need := float64(n); b.tokens < need {
+		if b.rate <= 0 || need > b.burst {
+			return false, math.MaxInt64
+		}
+
+		return false, time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+	}
+
+	b.tokens -= float64(n)
+
+	return true, 0
+}
+
+// Tokens returns the number of tokens available now.
+func (b *Bucket) Tokens(now time.Time) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+
+	return b.tokens
+}
+
+// Full reports whether the bucket has been refilled completely, i.e. it is idle.
+func (b *Bucket) Full(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+
+	return b.tokens >= b.burst
+}
+
+func (b *Bucket) update(rate float64, burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rate, b.burst = rate, float64(burst)
+	b.tokens = math.Min(b.tokens, b.burst)
+}
+
+func (b *Bucket) refill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+
+	if now.After(b.last) {
+		b.last = now
+	}
+}
+
+// New returns a new instance of Limiter that allows rate events per second per key
+// with bursts of up to burst events.
+func New(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*Bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow reports whether a single event for the key may happen now.
+func (l *Limiter) Allow(key string) bool {
+	ok, _ := l.AllowN(key, 1)
+	return ok
+}
+
+// AllowN reports whether n events for the key may happen now. If not, it also
+// returns the duration after which they will be allowed.
+func (l *Limiter) AllowN(key string, n int) (bool, time.Duration) {
+	return l.bucket(key).AllowN(l.now(), n)
+}
+
+// Tokens returns the number of events the key may have now without waiting.
+func (l *Limiter) Tokens(key string) float64 {
+	l.mu.Lock()
+	b, ok := l.buckets[key]
+	l.mu.Unlock()
+
+	if !ok {
+		return float64(l.burst)
+	}
+
+	return b.Tokens(l.now())
+}
+
+// Keys returns keys of buckets that are not pruned yet.
+func (l *Limiter) Keys() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return slices.Sorted(maps.Keys(l.buckets))
+}
+
+// Update changes the rate and the burst size of all buckets, e.g. on configuration reload.
+// Buckets keep their tokens up to the new burst size.
+func (l *Limiter) Update(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate, l.burst = rate, burst
+
+	for _, b := range l.buckets {
+		b.update(rate, burst)
+	}
+}
+
+// Prune removes buckets of keys that are idle, so that the limiter doesn't
+// grow unbounded with short-living keys.
+func (l *Limiter) Prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	for key, b := range l.buckets {
+		if b.Full(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *Limiter) bucket(key string) *Bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = NewBucket(l.rate, l.burst)
+		l.buckets[key] = b
+	}
+
+	return b
+}
+
+// NewQuota returns a new instance of Quota that allows limit per key within every window.
+func NewQuota(limit int64, window time.Duration) *Quota {
+	return &Quota{
+		limit:  limit,
+		window: window,
+		used:   make(map[string]int64),
+		now:    time.Now,
+	}
+}
+
+// AllowN adds n to the amount used by the key within the current window unless it would
+// exceed the limit. If so, it returns false and the duration until the next window.
+func (q *Quota) AllowN(key string, n int64) (bool, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.reset()
+
+	if q.used[key]+n > q.limit {
+		return false, q.start.Add(q.window).Sub(now)
+	}
+
+	q.used[key] += n
+
+	return true, 0
+}
+
+// Used returns the amount used by the key within the current window.
+func (q *Quota) Used(key string) int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.reset()
+
+	return q.used[key]
+}
+
+// Usage returns amounts used by all keys within the current window.
+func (q *Quota) Usage() map[string]int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.reset()
+
+	return maps.Clone(q.used)
+}
+
+// Limit returns the amount allowed per key within a window.
+func (q *Quota) Limit() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.limit
+}
+
+// Update changes the limit, e.g. on configuration reload. Amounts used within the current
+// window are kept.
+func (q *Quota) Update(limit int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.limit = limit
+}
+
+// reset starts a new window if the current one is over and returns the current time.
+func (q *Quota) reset() time.Time {
+	now := q.now()
+
+	if start := now.Truncate(q.window); !start.Equal(q.start) {
+		q.start = start
+		clear(q.used)
+	}
+
+	return now
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucket_AllowN(t *testing.T) {
+	start := time.Date(2021, 11, 10, 13, 18, 52, 0, time.UTC)
+
+	tests := map[string]struct {
+		giveRate  float64
+		giveBurst int
+		giveCalls []time.Duration
+		giveN     int
+		wantOK    bool
+		wantRetry time.Duration
+	}{
+		"allow within burst": {
+			giveRate:  1,
+			giveBurst: 2,
+			giveCalls: []time.Duration{0},
+			giveN:     1,
+			wantOK:    true,
+		},
+		"deny when burst is exhausted": {
+			giveRate:  2,
+			giveBurst: 2,
+			giveCalls: []time.Duration{0, 0},
+			giveN:     1,
+			wantOK:    false,
+			wantRetry: 500 * time.Millisecond,
+		},
+		"deny after refill is spent": {
+			giveRate:  2,
+			giveBurst: 2,
+			giveCalls: []time.Duration{0, 0, 500 * time.Millisecond},
+			giveN:     1,
+			wantOK:    false,
+			wantRetry: 500 * time.Millisecond,
+		},
+		"deny more than burst forever": {
+			giveRate:  10,
+			giveBurst: 5,
+			giveN:     6,
+			wantOK:    false,
+			wantRetry: time.Duration(1<<63 - 1),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			bucket := NewBucket(test.giveRate, test.giveBurst)
+
+			var elapsed time.Duration
+
+			for _, d := range test.giveCalls {
+				elapsed = d
+				ok, _ := bucket.AllowN(start.Add(d), 1)
+				require.True(t, ok)
+			}
+
+			ok, retry := bucket.AllowN(start.Add(elapsed), test.giveN)
+
+			require.Equal(t, test.wantOK, ok)
+			require.Equal(t, test.wantRetry, retry)
+		})
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2021, 11, 10, 13, 18, 52, 0, time.UTC)
+
+	limiter := New(1, 1)
+	limiter.now = func() time.Time { return now }
+
+	require.True(t, limiter.Allow("a"))
+	require.False(t, limiter.Allow("a"))
+	require.True(t, limiter.Allow("b"))
+
+	now = now.Add(time.Second)
+	limiter.Prune()
+
+	require.Empty(t, limiter.buckets)
+	require.True(t, limiter.Allow("a"))
+}
+
+func TestLimiter_Update(t *testing.T) {
+	now := time.Date(2021, 11, 10, 13, 18, 52, 0, time.UTC)
+
+	limiter := New(1, 5)
+	limiter.now = func() time.Time { return now }
+
+	require.True(t, limiter.Allow("a"))
+	require.InDelta(t, 4, limiter.Tokens("a"), 0)
+
+	limiter.Update(10, 2)
+
+	require.InDelta(t, 2, limiter.Tokens("a"), 0)
+	require.InDelta(t, 2, limiter.Tokens("b"), 0)
+	require.Equal(t, []string{"a"}, limiter.Keys())
+}
+
+func TestQuota_AllowN(t *testing.T) {
+	now := time.Date(2021, 11, 10, 13, 18, 52, 0, time.UTC)
+
+	quota := NewQuota(100, 24*time.Hour)
+	quota.now = func() time.Time { return now }
+
+	tests := []struct {
+		giveKey   string
+		giveN     int64
+		wantOK    bool
+		wantRetry time.Duration
+	}{
+		{giveKey: "a", giveN: 60, wantOK: true},
+		{giveKey: "a", giveN: 60, wantOK: false, wantRetry: 10*time.Hour + 41*time.Minute + 8*time.Second},
+		{giveKey: "b", giveN: 60, wantOK: true},
+		{giveKey: "a", giveN: 40, wantOK: true},
+	}
+
+	for _, test := range tests {
+		ok, retry := quota.AllowN(test.giveKey, test.giveN)
+
+		require.Equal(t, test.wantOK, ok)
+		require.Equal(t, test.wantRetry, retry)
+	}
+
+	require.Equal(t, map[string]int64{"a": 100, "b": 60}, quota.Usage())
+
+	now = now.Add(11 * time.Hour)
+
+	require.Zero(t, quota.Used("a"))
+	require.Empty(t, quota.Usage())
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Group is a Listener that runs several listeners together, e.g. readers of
+// multiple log sources.
+type Group struct {
+	listeners []Listener
+}
+
+// NewGroup returns a new instance of Group.
+func NewGroup(listeners ...Listener) *Group {
+	return &Group{listeners: listeners}
+}
+
+// ListenAndServe starts all listeners and returns the first error any of them returns.
+func (g *Group) ListenAndServe() error {
+	errsCh := make(chan error, len(g.listeners))
+
+	for _, l := range g.listeners {
+		go func() {
+			errsCh <- l.ListenAndServe()
+		}()
+	}
+
+	return <-errsCh
+}
+
+// Shutdown shuts all listeners down concurrently.
+func (g *Group) Shutdown(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, l := range g.listeners {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := l.Shutdown(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
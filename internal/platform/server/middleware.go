@@ -0,0 +1,51 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// InFlightLimiter is an HTTP middleware that rejects requests with 503 Service Unavailable
+// and the Retry-After header once the number of concurrently served requests reaches the limit,
+// so that clients back off instead of piling up requests on an overloaded server.
+type InFlightLimiter struct {
+	limit      atomic.Int64
+	retryAfter atomic.Int64
+	inFlight   atomic.Int64
+}
+
+// NewInFlightLimiter returns a new instance of InFlightLimiter. Zero limit disables it.
+func NewInFlightLimiter(limit int, retryAfter time.Duration) *InFlightLimiter {
+	l := &InFlightLimiter{}
+	l.Update(limit, retryAfter)
+
+	return l
+}
+
+// Update changes the limit of the running middleware.
+func (l *InFlightLimiter) Update(limit int, retryAfter time.Duration) {
+	l.limit.Store(int64(limit))
+	l.retryAfter.Store(int64(retryAfter))
+}
+
+// Middleware wraps the handler with the limit.
+func (l *InFlightLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer l.inFlight.Add(-1)
+
+		if n, limit := l.inFlight.Add(1), l.limit.Load(); limit > 0 && n > limit {
+			// Delays are rounded up to whole seconds, as clients treat zero as no delay at all.
+			retryAfter := time.Duration(l.retryAfter.Load())
+
+			w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds())))))
+			http.Error(w, "server is overloaded", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"io"
+	"sync"
 	"time"
 )
 
@@ -20,6 +21,8 @@ type Message struct {
 type StreamReader struct {
 	reader  io.ReadCloser
 	handler Handler
+	done    chan struct{}
+	close   sync.Once
 }
 
 // NewStreamReader returns a new instance of StreamReader.
@@ -27,13 +30,24 @@ func NewStreamReader(reader io.ReadCloser, handler Handler) *StreamReader {
 	return &StreamReader{
 		reader:  reader,
 		handler: handler,
+		done:    make(chan struct{}),
 	}
 }
 
 // ListenAndServe continuously reads bytes from io.Reader
 // and invokes handlers once the complete message is received.
+// It returns nil once the reader is shut down and messages
+// available by then are handled.
 func (l *StreamReader) ListenAndServe() error {
 	for {
+		var draining bool
+
+		select {
+		case <-l.done:
+			draining = true
+		default:
+		}
+
 		scanner := bufio.NewScanner(l.reader)
 		scanner.Split(bufio.ScanLines)
 
@@ -47,11 +61,20 @@ func (l *StreamReader) ListenAndServe() error {
 			}
 		}
 
-		time.Sleep(time.Second)
+		if draining {
+			return nil
+		}
+
+		select {
+		case <-l.done:
+		case <-time.After(time.Second):
+		}
 	}
 }
 
-// Shutdown closes the io.Reader.
+// Shutdown stops reading once the io.Reader has no more messages, and closes it.
 func (l *StreamReader) Shutdown(context.Context) error {
+	l.close.Do(func() { close(l.done) })
+
 	return l.reader.Close()
 }
@@ -0,0 +1,21 @@
+package server
+
+import (
+	"net/http"
+)
+
+// TLSServer is a Listener of HTTP server serving TLS connections with the certificates
+// from the TLSConfig of the server.
+type TLSServer struct {
+	*http.Server
+}
+
+// NewTLSServer returns a new instance of TLSServer.
+func NewTLSServer(srv *http.Server) TLSServer {
+	return TLSServer{Server: srv}
+}
+
+// ListenAndServe listens on the TCP network address and serves TLS connections.
+func (s TLSServer) ListenAndServe() error {
+	return s.ListenAndServeTLS("", "")
+}
@@ -4,6 +4,7 @@ package storage
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 )
 
 var (
@@ -11,6 +12,8 @@ var (
 	ErrMissingID = errors.New("missing record ID")
 	// ErrNotFound is an error when record is not found.
 	ErrNotFound = errors.New("record not found")
+	// ErrNoIndex is an error when there's no secondary index with the given name.
+	ErrNoIndex = errors.New("no such index")
 )
 
 type (
@@ -25,9 +28,23 @@ type (
 		ID() ID
 	}
 
+	// Sizer is an optional interface of records reporting their approximate size in bytes,
+	// which is used to track memory usage of the storage.
+	Sizer interface {
+		Size() int
+	}
+
 	// InMemory is a simple in-memory storage.
 	InMemory[T Record] struct {
 		records sync.Map
+		count   atomic.Int64
+		bytes   atomic.Int64
+		seq     atomic.Int64
+
+		// indexes are secondary indexes by names. Changes of records are serialized by mu
+		// if there are any, so that indexes are updated in the order of changes.
+		mu      sync.RWMutex
+		indexes map[string]*secondary[T]
 	}
 )
 
@@ -70,11 +87,123 @@ func (s *InMemory[T]) Find(match Matcher[T]) ([]T, error) {
 
 // Insert an entry and overrides existing one.
 func (s *InMemory[T]) Insert(r T) error {
+	_, _, err := s.Swap(r)
+	return err
+}
+
+// Swap atomically inserts the record overriding the existing one, if any. It returns the
+// overridden record and whether there was one.
+func (s *InMemory[T]) Swap(r T) (T, bool, error) {
+	if r.ID() == "" {
+		var zero T
+		return zero, false, ErrMissingID
+	}
+
+	var (
+		prev   any
+		loaded bool
+	)
+
+	s.update(r.ID(), func() (any, any) {
+		prev, loaded = s.records.Swap(r.ID(), r)
+		if loaded {
+			s.bytes.Add(-int64(sizeOf(prev)))
+		} else {
+			s.count.Add(1)
+		}
+
+		s.bytes.Add(int64(sizeOf(r)))
+
+		return prev, r
+	})
+
+	if !loaded {
+		var zero T
+		return zero, false, nil
+	}
+
+	return prev.(T), true, nil
+}
+
+// InsertIfAbsent atomically inserts the record unless there's one with the same ID already.
+// It returns the stored record and whether it's the given one.
+func (s *InMemory[T]) InsertIfAbsent(r T) (T, bool, error) {
 	if r.ID() == "" {
-		return ErrMissingID
+		var zero T
+		return zero, false, ErrMissingID
 	}
 
-	s.records.Store(r.ID(), r)
+	var (
+		stored   any
+		inserted bool
+	)
+
+	s.update(r.ID(), func() (any, any) {
+		prev, loaded := s.records.LoadOrStore(r.ID(), r)
+		if loaded {
+			stored = prev
+			return nil, nil
+		}
+
+		s.count.Add(1)
+		s.bytes.Add(int64(sizeOf(r)))
+
+		stored, inserted = r, true
+
+		return nil, r
+	})
+
+	return stored.(T), inserted, nil
+}
+
+// Delete removes the record with the given ID, if any.
+func (s *InMemory[T]) Delete(id ID) {
+	s.DeleteIf(id, func(T) bool { return true })
+}
+
+// DeleteIf atomically removes the record with the given ID if it matches, and reports whether
+// it's removed, e.g. to delete a record unless it has been replaced since it was read.
+func (s *InMemory[T]) DeleteIf(id ID, match Matcher[T]) bool {
+	var deleted bool
+
+	s.exclusive(id, func() (any, any) {
+		prev, loaded := s.records.Load(id)
+		if !loaded || !match(prev.(T)) {
+			return nil, nil
+		}
+
+		s.records.Delete(id)
+		s.count.Add(-1)
+		s.bytes.Add(-int64(sizeOf(prev)))
+
+		deleted = true
+
+		return prev, nil
+	})
+
+	return deleted
+}
+
+// NextSeq returns the next number of a sequence increasing with every call, starting at 1,
+// e.g. to number records in the order of their insertion.
+func (s *InMemory[T]) NextSeq() int64 {
+	return s.seq.Add(1)
+}
+
+// Len returns the number of stored records.
+func (s *InMemory[T]) Len() int {
+	return int(s.count.Load())
+}
+
+// Bytes returns the approximate size of stored records, it's zero unless they implement Sizer.
+func (s *InMemory[T]) Bytes() int64 {
+	return s.bytes.Load()
+}
+
+func sizeOf(v any) int {
+	if s, ok := v.(Sizer); ok {
+		return s.Size()
+	}
 
-	return nil
+	return 0
 }
@@ -8,7 +8,8 @@ import (
 )
 
 type testItem struct {
-	Id string
+	Id    string
+	Value string
 }
 
 func (i testItem) ID() ID {
@@ -29,6 +30,10 @@ func TestInMemory_Insert(t *testing.T) {
 			giveItems: []testItem{{Id: "test-1"}, {Id: "test-2"}},
 			wantItems: []testItem{{Id: "test-1"}, {Id: "test-2"}},
 		},
+		"duplicate record": {
+			giveItems: []testItem{{Id: "test"}, {Id: "test"}},
+			wantItems: []testItem{{Id: "test"}},
+		},
 		"error - missing ID": {
 			giveItems: []testItem{{Id: ""}},
 			wantErr:   ErrMissingID,
@@ -56,10 +61,73 @@ func TestInMemory_Insert(t *testing.T) {
 
 			require.NoError(t, err)
 			require.ElementsMatch(t, test.wantItems, items)
+			require.Equal(t, len(test.wantItems), store.Len())
 		})
 	}
 }
 
+func TestInMemory_InsertIfAbsent(t *testing.T) {
+	store := NewInMemory[testItem]()
+
+	tests := []struct {
+		giveItem     testItem
+		wantItem     testItem
+		wantInserted bool
+		wantErr      error
+	}{
+		{giveItem: testItem{Id: "1", Value: "first"}, wantItem: testItem{Id: "1", Value: "first"}, wantInserted: true},
+		{giveItem: testItem{Id: "1", Value: "second"}, wantItem: testItem{Id: "1", Value: "first"}},
+		{giveItem: testItem{Value: "no ID"}, wantErr: ErrMissingID},
+	}
+
+	for _, test := range tests {
+		item, inserted, err := store.InsertIfAbsent(test.giveItem)
+
+		require.ErrorIs(t, err, test.wantErr)
+		require.Equal(t, test.wantItem, item)
+		require.Equal(t, test.wantInserted, inserted)
+	}
+
+	require.Equal(t, 1, store.Len())
+}
+
+func TestInMemory_Swap(t *testing.T) {
+	store := NewInMemory[testItem]()
+
+	tests := []struct {
+		giveItem   testItem
+		wantItem   testItem
+		wantLoaded bool
+		wantErr    error
+	}{
+		{giveItem: testItem{Id: "1", Value: "first"}},
+		{giveItem: testItem{Id: "1", Value: "second"}, wantItem: testItem{Id: "1", Value: "first"}, wantLoaded: true},
+		{giveItem: testItem{Value: "no ID"}, wantErr: ErrMissingID},
+	}
+
+	for _, test := range tests {
+		item, loaded, err := store.Swap(test.giveItem)
+
+		require.ErrorIs(t, err, test.wantErr)
+		require.Equal(t, test.wantItem, item)
+		require.Equal(t, test.wantLoaded, loaded)
+	}
+
+	require.Equal(t, 1, store.Len())
+}
+
+func TestInMemory_DeleteIf(t *testing.T) {
+	store := NewInMemory[testItem]()
+	require.NoError(t, store.Insert(testItem{Id: "1", Value: "replaced"}))
+
+	require.False(t, store.DeleteIf("1", func(item testItem) bool { return item.Value == "read" }))
+	require.False(t, store.DeleteIf("2", func(testItem) bool { return true }))
+	require.Equal(t, 1, store.Len())
+
+	require.True(t, store.DeleteIf("1", func(item testItem) bool { return item.Value == "replaced" }))
+	require.Zero(t, store.Len())
+}
+
 func TestInMemory_Get(t *testing.T) {
 	tests := map[string]struct {
 		giveItems []testItem
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Namespaced is a set of InMemory storages isolated from each other, e.g. of different
+// tenants. Records of different namespaces may have the same ID.
+type Namespaced[T Record] struct {
+	mu     sync.RWMutex
+	spaces map[string]*InMemory[T]
+	// indexes are secondary indexes of every namespace by names.
+	indexes map[string]Extractor[T]
+}
+
+// NewNamespaced returns a new instance of Namespaced storage for a given type.
+func NewNamespaced[T Record]() *Namespaced[T] {
+	return &Namespaced[T]{spaces: make(map[string]*InMemory[T]), indexes: make(map[string]Extractor[T])}
+}
+
+// AddIndex registers the secondary index in all namespaces, including ones created later, see InMemory.AddIndex.
+func (n *Namespaced[T]) AddIndex(name string, extract Extractor[T]) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.indexes[name] = extract
+
+	for _, s := range n.spaces {
+		s.AddIndex(name, extract)
+	}
+}
+
+// Namespace returns the storage of the namespace, creating it if needed.
+func (n *Namespaced[T]) Namespace(name string) *InMemory[T] {
+	n.mu.RLock()
+	s, ok := n.spaces[name]
+	n.mu.RUnlock()
+
+	if ok {
+		return s
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if s, ok = n.spaces[name]; !ok {
+		s = NewInMemory[T]()
+		for index, extract := range n.indexes {
+			s.AddIndex(index, extract)
+		}
+
+		n.spaces[name] = s
+	}
+
+	return s
+}
+
+// Lookup returns the storage of the namespace if it exists. Unlike Namespace, it doesn't create one,
+// e.g. for reads of names given by clients.
+func (n *Namespaced[T]) Lookup(name string) (*InMemory[T], bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	s, ok := n.spaces[name]
+
+	return s, ok
+}
+
+// Names returns sorted names of all namespaces.
+func (n *Namespaced[T]) Names() []string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	return slices.Sorted(maps.Keys(n.spaces))
+}
+
+// Len returns the number of records in all namespaces.
+func (n *Namespaced[T]) Len() int {
+	var total int
+
+	for _, name := range n.Names() {
+		total += n.Namespace(name).Len()
+	}
+
+	return total
+}
+
+// Bytes returns the approximate size of records in all namespaces.
+func (n *Namespaced[T]) Bytes() int64 {
+	var total int64
+
+	for _, name := range n.Names() {
+		total += n.Namespace(name).Bytes()
+	}
+
+	return total
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaced(t *testing.T) {
+	store := NewNamespaced[testItem]()
+
+	require.NoError(t, store.Namespace("team-a").Insert(testItem{Id: "1"}))
+	require.NoError(t, store.Namespace("team-b").Insert(testItem{Id: "1"}))
+	require.NoError(t, store.Namespace("team-b").Insert(testItem{Id: "2"}))
+
+	require.Equal(t, []string{"team-a", "team-b"}, store.Names())
+	require.Equal(t, 1, store.Namespace("team-a").Len())
+	require.Equal(t, 3, store.Len())
+
+	store.Namespace("team-a").Delete("1")
+
+	_, err := store.Namespace("team-a").Get("1")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	item, err := store.Namespace("team-b").Get("1")
+	require.NoError(t, err)
+	require.Equal(t, testItem{Id: "1"}, item)
+	require.Equal(t, 2, store.Len())
+
+	_, ok := store.Lookup("team-c")
+	require.False(t, ok)
+	require.Equal(t, []string{"team-a", "team-b"}, store.Names())
+
+	space, ok := store.Lookup("team-b")
+	require.True(t, ok)
+	require.Equal(t, 2, space.Len())
+}
+
+func TestNamespaced_AddIndex(t *testing.T) {
+	store := NewNamespaced[testItem]()
+
+	require.NoError(t, store.Namespace("team-a").Insert(testItem{Id: "1", Value: "x"}))
+
+	store.AddIndex("value", func(item testItem) []string { return []string{item.Value} })
+
+	require.NoError(t, store.Namespace("team-b").Insert(testItem{Id: "1", Value: "x"}))
+
+	// Indexes are added to existing and new namespaces.
+	for _, name := range []string{"team-a", "team-b"} {
+		items, err := store.Namespace(name).FindBy("value", []string{"x"}, func(testItem) bool { return true })
+		require.NoError(t, err)
+		require.Equal(t, []testItem{{Id: "1", Value: "x"}}, items)
+	}
+}
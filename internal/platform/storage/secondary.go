@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+)
+
+type (
+	// Extractor returns values a record is indexed by in a secondary index, e.g. its severity.
+	Extractor[T Record] func(value T) []string
+
+	// secondary is a secondary index of record IDs by values of the extractor.
+	secondary[T Record] struct {
+		extract Extractor[T]
+		ids     map[string]map[ID]struct{}
+	}
+)
+
+// AddIndex registers the secondary index of records by values of the extractor, replacing the index
+// with the same name if any. Stored records are indexed right away, and then as they are inserted.
+func (s *InMemory[T]) AddIndex(name string, extract Extractor[T]) {
+	idx := &secondary[T]{extract: extract, ids: make(map[string]map[ID]struct{})}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Records can't be changed concurrently, as changes hold mu.
+	s.records.Range(func(k, v any) bool {
+		idx.add(k.(ID), v.(T))
+		return true
+	})
+
+	if s.indexes == nil {
+		s.indexes = make(map[string]*secondary[T])
+	}
+
+	s.indexes[name] = idx
+}
+
+// Count returns the number of records having any of the values in the index, e.g. to estimate
+// the cost of FindBy. It returns false if there's no such index.
+func (s *InMemory[T]) Count(name string, values ...string) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	idx, ok := s.indexes[name]
+	if !ok {
+		return 0, false
+	}
+
+	var n int
+	for _, v := range slices.Compact(slices.Sorted(slices.Values(values))) {
+		n += len(idx.ids[v])
+	}
+
+	return n, true
+}
+
+// FindBy finds matching records having any of the values in the index, without scanning the whole set.
+// Records are returned in no particular order.
+func (s *InMemory[T]) FindBy(name string, values []string, match Matcher[T]) ([]T, error) {
+	s.mu.RLock()
+
+	idx, ok := s.indexes[name]
+	if !ok {
+		s.mu.RUnlock()
+		return nil, fmt.Errorf("%w: %q", ErrNoIndex, name)
+	}
+
+	var ids []ID
+	for _, v := range slices.Compact(slices.Sorted(slices.Values(values))) {
+		for id := range idx.ids[v] {
+			ids = append(ids, id)
+		}
+	}
+
+	s.mu.RUnlock()
+
+	// Records having several of the values are found once.
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	var res []T
+
+	for _, id := range ids {
+		v, ok := s.records.Load(id)
+		if !ok {
+			continue
+		}
+
+		// Records found are verified, as they may have been replaced since.
+		if t := v.(T); slices.ContainsFunc(idx.extract(t), func(v string) bool {
+			return slices.Contains(values, v)
+		}) && match(t) {
+			res = append(res, t)
+		}
+	}
+
+	return res, nil
+}
+
+// update applies the change of the record of the ID, which returns the previous and the next record,
+// if any, and updates secondary indexes accordingly. Changes are concurrent if there are no indexes.
+func (s *InMemory[T]) update(id ID, change func() (prev, next any)) {
+	s.mu.RLock()
+	if len(s.indexes) == 0 {
+		defer s.mu.RUnlock()
+
+		change()
+
+		return
+	}
+	s.mu.RUnlock()
+
+	s.exclusive(id, change)
+}
+
+// exclusive applies the change like update, but serialized with all other changes, e.g. if it depends
+// on the current record.
+func (s *InMemory[T]) exclusive(id ID, change func() (prev, next any)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, next := change()
+
+	for _, idx := range s.indexes {
+		if prev != nil {
+			idx.remove(id, prev.(T))
+		}
+
+		if next != nil {
+			idx.add(id, next.(T))
+		}
+	}
+}
+
+func (i *secondary[T]) add(id ID, r T) {
+	for _, v := range i.extract(r) {
+		ids, ok := i.ids[v]
+		if !ok {
+			ids = make(map[ID]struct{})
+			i.ids[v] = ids
+		}
+
+		ids[id] = struct{}{}
+	}
+}
+
+func (i *secondary[T]) remove(id ID, r T) {
+	for _, v := range i.extract(r) {
+		if ids, ok := i.ids[v]; ok {
+			if delete(ids, id); len(ids) == 0 {
+				delete(i.ids, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemory_FindBy(t *testing.T) {
+	store := NewInMemory[testItem]()
+
+	require.NoError(t, store.Insert(testItem{Id: "1", Value: "a"}))
+	require.NoError(t, store.Insert(testItem{Id: "2", Value: "b c"}))
+
+	// Stored records are indexed when the index is added.
+	store.AddIndex("words", func(item testItem) []string { return strings.Fields(item.Value) })
+
+	require.NoError(t, store.Insert(testItem{Id: "3", Value: "a c"}))
+	require.NoError(t, store.Insert(testItem{Id: "4", Value: "b"}))
+	require.NoError(t, store.Insert(testItem{Id: "4", Value: "d"}))
+
+	_, _, err := store.InsertIfAbsent(testItem{Id: "5", Value: "c"})
+	require.NoError(t, err)
+
+	store.Delete("1")
+
+	all := func(testItem) bool { return true }
+
+	tests := map[string]struct {
+		giveValues []string
+		giveMatch  Matcher[testItem]
+		wantIDs    []string
+		wantCount  int
+	}{
+		"one value": {
+			giveValues: []string{"c"},
+			giveMatch:  all,
+			wantIDs:    []string{"2", "3", "5"},
+			wantCount:  3,
+		},
+		"any of values": {
+			giveValues: []string{"a", "b", "a"},
+			giveMatch:  all,
+			wantIDs:    []string{"2", "3"},
+			wantCount:  2,
+		},
+		"replaced value": {
+			giveValues: []string{"d"},
+			giveMatch:  all,
+			wantIDs:    []string{"4"},
+			wantCount:  1,
+		},
+		"matcher": {
+			giveValues: []string{"c"},
+			giveMatch:  func(item testItem) bool { return item.Id != "3" },
+			wantIDs:    []string{"2", "5"},
+			wantCount:  3,
+		},
+		"unknown value": {
+			giveValues: []string{"x"},
+			giveMatch:  all,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			items, err := store.FindBy("words", test.giveValues, test.giveMatch)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, item := range items {
+				ids = append(ids, item.Id)
+			}
+
+			slices.Sort(ids)
+			require.Equal(t, test.wantIDs, ids)
+
+			count, ok := store.Count("words", test.giveValues...)
+			require.True(t, ok)
+			require.Equal(t, test.wantCount, count)
+		})
+	}
+
+	_, err = store.FindBy("missing", []string{"a"}, all)
+	require.ErrorIs(t, err, ErrNoIndex)
+
+	_, ok := store.Count("missing", "a")
+	require.False(t, ok)
+}
+
+func TestInMemory_FindByConcurrent(t *testing.T) {
+	store := NewInMemory[testItem]()
+	store.AddIndex("value", func(item testItem) []string { return []string{item.Value} })
+
+	var wg sync.WaitGroup
+
+	for w := range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range 500 {
+				id := strconv.Itoa(i % 50)
+
+				if (i+w)%7 == 0 {
+					store.Delete(ID(id))
+					continue
+				}
+
+				require.NoError(t, store.Insert(testItem{Id: id, Value: strconv.Itoa(w % 2)}))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// Found records have the value, despite concurrent replacements.
+	for _, value := range []string{"0", "1"} {
+		items, err := store.FindBy("value", []string{value}, func(testItem) bool { return true })
+		require.NoError(t, err)
+
+		want, err := store.Find(func(item testItem) bool { return item.Value == value })
+		require.NoError(t, err)
+		require.ElementsMatch(t, want, items)
+	}
+}
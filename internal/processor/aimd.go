@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// AIMD is an adaptive send rate controller. It increases the allowed rate additively
+// after every successful send and decreases it multiplicatively when the server is
+// overloaded, similarly to TCP congestion control.
+type AIMD struct {
+	mu       sync.Mutex
+	rate     float64
+	minRate  float64
+	maxRate  float64
+	increase float64
+	decrease float64
+	next     time.Time
+}
+
+// NewAIMD returns a new instance of AIMD that allows between minRate and maxRate sends
+// per second, starting at maxRate.
+func NewAIMD(minRate, maxRate float64) *AIMD {
+	return &AIMD{
+		rate:     maxRate,
+		minRate:  minRate,
+		maxRate:  maxRate,
+		increase: math.Max(minRate, maxRate/100),
+		decrease: 0.5,
+	}
+}
+
+// Wait blocks until the next send is allowed by the current rate.
+func (a *AIMD) Wait(ctx context.Context) error {
+	a.mu.Lock()
+
+	now := time.Now()
+	at := a.next
+
+	if at.Before(now) {
+		at = now
+	}
+
+	a.next = at.Add(time.Duration(float64(time.Second) / a.rate))
+
+	a.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(at)):
+		return nil
+	}
+}
+
+// Success increases the send rate.
+func (a *AIMD) Success() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rate = math.Min(a.maxRate, a.rate+a.increase)
+}
+
+// Overload decreases the send rate.
+func (a *AIMD) Overload() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rate = math.Max(a.minRate, a.rate*a.decrease)
+}
+
+// Rate returns the current send rate per second.
+func (a *AIMD) Rate() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.rate
+}
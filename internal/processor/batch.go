@@ -0,0 +1,248 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/api"
+)
+
+type (
+	// BatchOptions contains batching and retry settings of BatchOutput.
+	BatchOptions struct {
+		// Size is the max number of entries sent at once.
+		Size int
+		// FlushInterval is the max time entries wait in an incomplete batch.
+		FlushInterval time.Duration
+		// MaxRetries is the number of attempts to resend a failed batch.
+		MaxRetries int
+		// RetryBackoff is the delay before the first retry, doubled on every next one.
+		RetryBackoff time.Duration
+		// MaxBackoff caps the delay between retries.
+		MaxBackoff time.Duration
+		// MaxOverloadWait is the max time a batch is retried while the server is overloaded,
+		// as such retries don't count towards MaxRetries. Zero disables the limit.
+		MaxOverloadWait time.Duration
+		// MinRate and MaxRate bound the number of batches sent per second, which is adapted
+		// to the server load. Zero MaxRate disables the adaptive rate.
+		MinRate float64
+		MaxRate float64
+		// Metrics are updated with sent, retried, dropped and pending entries.
+		Metrics Metrics
+	}
+
+	// BatchOutput is an Output that groups Log entries into batches and retries
+	// failed sends before passing them to the wrapped Output.
+	BatchOutput struct {
+		out    Output
+		opts   BatchOptions
+		rate   *AIMD
+		logger *slog.Logger
+
+		mu    sync.Mutex
+		batch []api.Log
+
+		// sendMu keeps batches in order by allowing a single send at a time.
+		sendMu sync.Mutex
+
+		stop chan struct{}
+		done chan struct{}
+	}
+)
+
+// DefaultBatchOptions returns batching settings suitable for most outputs.
+func DefaultBatchOptions() BatchOptions {
+	return BatchOptions{
+		Size:            100,
+		FlushInterval:   time.Second,
+		MaxRetries:      3,
+		RetryBackoff:    500 * time.Millisecond,
+		MaxBackoff:      30 * time.Second,
+		MaxOverloadWait: 5 * time.Minute,
+		MinRate:         0.2,
+		MaxRate:         50,
+	}
+}
+
+// NewBatchOutput returns a new instance of BatchOutput and starts flushing
+// incomplete batches in background.
+func NewBatchOutput(out Output, opts BatchOptions, logger *slog.Logger) *BatchOutput {
+	if opts.Size <= 0 {
+		opts.Size = 1
+	}
+
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+
+	b := &BatchOutput{
+		out:    out,
+		opts:   opts,
+		logger: logger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	if opts.MaxRate > 0 {
+		b.rate = NewAIMD(opts.MinRate, opts.MaxRate)
+	}
+
+	go b.run()
+
+	return b
+}
+
+// Send adds Log entries to the current batch. Complete batches are sent synchronously,
+// which slows down the caller if the output can't keep up.
+func (b *BatchOutput) Send(ctx context.Context, logs []api.Log) error {
+	b.opts.Metrics.Pending.Add(int64(len(logs)))
+
+	b.mu.Lock()
+	b.batch = append(b.batch, logs...)
+
+	var full [][]api.Log
+
+	for len(b.batch) >= b.opts.Size {
+		full = append(full, b.batch[:b.opts.Size:b.opts.Size])
+		b.batch = b.batch[b.opts.Size:]
+	}
+
+	b.mu.Unlock()
+
+	for _, batch := range full {
+		b.deliver(ctx, batch)
+	}
+
+	return nil
+}
+
+// Close sends the remaining entries and closes the wrapped Output.
+func (b *BatchOutput) Close(ctx context.Context) error {
+	close(b.stop)
+	<-b.done
+
+	b.flush(ctx)
+
+	return b.out.Close(ctx)
+}
+
+func (b *BatchOutput) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.flush(context.Background())
+		}
+	}
+}
+
+func (b *BatchOutput) flush(ctx context.Context) {
+	b.mu.Lock()
+	batch := b.batch
+	b.batch = nil
+	b.mu.Unlock()
+
+	if len(batch) > 0 {
+		b.deliver(ctx, batch)
+	}
+}
+
+// deliver sends the batch retrying RetryableError failures with exponential backoff, or
+// after the delay requested by the server if it's longer. Retries of overload responses
+// don't count as attempts, as the server explicitly asked to wait, but they are limited
+// by MaxOverloadWait. Failed batches are dropped once all attempts fail, so that a broken
+// output doesn't stall the others.
+func (b *BatchOutput) deliver(ctx context.Context, batch []api.Log) {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	defer b.opts.Metrics.Pending.Add(-int64(len(batch)))
+
+	backoff := b.opts.RetryBackoff
+	start := time.Now()
+
+	for attempt := 0; ; {
+		if b.rate != nil {
+			if err := b.rate.Wait(ctx); err != nil {
+				b.drop(batch, "dropping batch", err)
+				return
+			}
+		}
+
+		err := b.out.Send(ctx, batch)
+		if err == nil {
+			if b.rate != nil {
+				b.rate.Success()
+			}
+
+			b.opts.Metrics.Sent.Add(uint64(len(batch)))
+
+			return
+		}
+
+		var rejected *RejectedError
+		if errors.As(err, &rejected) {
+			dropped := make([]api.Log, 0, len(rejected.Rejected))
+
+			for _, r := range rejected.Rejected {
+				if r.Index >= 0 && r.Index < len(batch) {
+					dropped = append(dropped, batch[r.Index])
+				}
+			}
+
+			b.opts.Metrics.Sent.Add(uint64(len(batch) - len(dropped)))
+			b.drop(dropped, "dropping rejected entries", err)
+
+			return
+		}
+
+		var retryable *RetryableError
+		if !errors.As(err, &retryable) {
+			b.drop(batch, "dropping batch after permanent failure", err)
+			return
+		}
+
+		if retryable.Overload && b.rate != nil {
+			b.rate.Overload()
+		}
+
+		delay := max(backoff, retryable.After)
+
+		if !retryable.Overload {
+			if attempt++; attempt > b.opts.MaxRetries {
+				b.drop(batch, "dropping batch after failed retries", err)
+				return
+			}
+		} else if b.opts.MaxOverloadWait > 0 && time.Since(start)+delay > b.opts.MaxOverloadWait {
+			b.drop(batch, "dropping batch after waiting for overloaded server", err)
+			return
+		}
+
+		b.logger.Warn("retrying batch", "error", err, "attempt", attempt, "delay", delay)
+		b.opts.Metrics.Retried.Add(uint64(len(batch)))
+
+		select {
+		case <-ctx.Done():
+			b.drop(batch, "dropping batch", ctx.Err())
+			return
+		case <-time.After(delay):
+			if backoff *= 2; b.opts.MaxBackoff > 0 && backoff > b.opts.MaxBackoff {
+				backoff = b.opts.MaxBackoff
+			}
+		}
+	}
+}
+
+func (b *BatchOutput) drop(batch []api.Log, msg string, err error) {
+	b.logger.Error(msg, "error", err, "entries", len(batch))
+	b.opts.Metrics.Dropped.Add(uint64(len(batch)))
+}
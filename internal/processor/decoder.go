@@ -1,8 +1,12 @@
 package processor
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/dyptan-io/log-management/v2/api"
@@ -11,8 +15,19 @@ import (
 type (
 	DecoderJSON struct{}
 	raw         map[string]any
+
+	// DecoderText decodes plain text lines into Log messages with the given severity.
+	// The ID is derived from the line and the time it's decoded at, so identical lines,
+	// e.g. repeated errors, are stored as separate entries.
+	DecoderText struct {
+		Severity string
+	}
 )
 
+// textSeq numbers lines decoded by DecoderText, so that IDs of identical lines decoded
+// at the same time differ.
+var textSeq atomic.Uint64
+
 func (DecoderJSON) Decode(b []byte) (api.Log, error) {
 	var r raw
 
@@ -56,3 +71,19 @@ func (r raw) time(name string) time.Time {
 
 	return t
 }
+
+func (d DecoderText) Decode(b []byte) (api.Log, error) {
+	now := time.Now().UTC()
+
+	h := sha256.New()
+	_ = binary.Write(h, binary.BigEndian, []int64{now.UnixNano(), int64(textSeq.Add(1))})
+	h.Write(b)
+
+	return api.Log{
+		Id:         hex.EncodeToString(h.Sum(nil)[:16]),
+		Severity:   d.Severity,
+		Message:    string(b),
+		Timestamp:  now,
+		Attributes: map[string]any{},
+	}, nil
+}
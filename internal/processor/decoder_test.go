@@ -0,0 +1,24 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoderText_Decode(t *testing.T) {
+	d := DecoderText{Severity: "Info"}
+
+	first, err := d.Decode([]byte("connection reset"))
+	require.NoError(t, err)
+
+	second, err := d.Decode([]byte("connection reset"))
+	require.NoError(t, err)
+
+	require.Equal(t, "connection reset", first.Message)
+	require.Equal(t, "Info", first.Severity)
+	require.Len(t, first.Id, 32)
+
+	// Repeated lines are separate entries.
+	require.NotEqual(t, first.Id, second.Id)
+}
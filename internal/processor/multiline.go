@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/server"
+)
+
+type (
+	// MultilineOptions contains rules of joining lines into a single message.
+	MultilineOptions struct {
+		// Pattern matches continuation lines, e.g. indented lines of a stack trace.
+		Pattern *regexp.Regexp
+		// Start inverts the Pattern, so that it matches first lines of messages
+		// and all other lines are continuation lines.
+		Start bool
+		// MaxLines limits the number of lines in a message, 0 means no limit.
+		MaxLines int
+		// Timeout is the time to wait for continuation lines before the message is complete.
+		Timeout time.Duration
+	}
+
+	// Multiline joins lines that belong to the same message, e.g. stack traces, before
+	// passing them to the next handler.
+	Multiline struct {
+		next   server.Handler
+		opts   MultilineOptions
+		logger *slog.Logger
+
+		mu    sync.Mutex
+		lines [][]byte
+		timer *time.Timer
+	}
+)
+
+// NewMultiline returns a new instance of Multiline.
+func NewMultiline(next server.Handler, opts MultilineOptions, logger *slog.Logger) *Multiline {
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+
+	return &Multiline{
+		next:   next,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Handle buffers the line until the message is complete.
+func (m *Multiline) Handle(msg server.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var err error
+
+	if !m.isContinuation(msg.Data) || (m.opts.MaxLines > 0 && len(m.lines) >= m.opts.MaxLines) {
+		err = m.flush()
+	}
+
+	// The handled data may be reused by the reader, so it's copied.
+	m.lines = append(m.lines, bytes.Clone(msg.Data))
+
+	if m.timer == nil {
+		m.timer = time.AfterFunc(m.opts.Timeout, m.expire)
+	} else {
+		m.timer.Reset(m.opts.Timeout)
+	}
+
+	return err
+}
+
+// Flush passes the buffered message to the next handler.
+func (m *Multiline) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+
+	return m.flush()
+}
+
+func (m *Multiline) expire() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.flush(); err != nil {
+		m.logger.Error("handling multiline message", "error", err)
+	}
+}
+
+func (m *Multiline) flush() error {
+	if len(m.lines) == 0 {
+		return nil
+	}
+
+	data := bytes.Join(m.lines, []byte("\n"))
+	m.lines = nil
+
+	return m.next(server.Message{Data: data})
+}
+
+func (m *Multiline) isContinuation(line []byte) bool {
+	if len(m.lines) == 0 {
+		return false
+	}
+
+	return m.opts.Pattern.Match(line) != m.opts.Start
+}
@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"io"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/server"
+)
+
+func TestMultiline_Handle(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := map[string]struct {
+		giveOpts  MultilineOptions
+		giveLines []string
+		wantMsgs  []string
+	}{
+		"join continuation lines": {
+			giveOpts:  MultilineOptions{Pattern: regexp.MustCompile(`^\s`)},
+			giveLines: []string{"error", "  at main", "  at init", "info"},
+			wantMsgs:  []string{"error\n  at main\n  at init", "info"},
+		},
+		"join lines until the next first line": {
+			giveOpts:  MultilineOptions{Pattern: regexp.MustCompile(`^\d{4}-`), Start: true},
+			giveLines: []string{"2021-11-10 error", "Caused by: timeout", "2021-11-10 info"},
+			wantMsgs:  []string{"2021-11-10 error\nCaused by: timeout", "2021-11-10 info"},
+		},
+		"limit lines": {
+			giveOpts:  MultilineOptions{Pattern: regexp.MustCompile(`^\s`), MaxLines: 2},
+			giveLines: []string{"error", " 1", " 2", " 3"},
+			wantMsgs:  []string{"error\n 1", " 2\n 3"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var msgs []string
+
+			m := NewMultiline(func(msg server.Message) error {
+				msgs = append(msgs, string(msg.Data))
+				return nil
+			}, test.giveOpts, logger)
+
+			for _, line := range test.giveLines {
+				require.NoError(t, m.Handle(server.Message{Data: []byte(line)}))
+			}
+
+			require.NoError(t, m.Flush())
+			require.Equal(t, test.wantMsgs, msgs)
+		})
+	}
+}
+
+func TestMultiline_Timeout(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	msgs := make(chan string, 1)
+
+	m := NewMultiline(func(msg server.Message) error {
+		msgs <- string(msg.Data)
+		return nil
+	}, MultilineOptions{Pattern: regexp.MustCompile(`^\s`), Timeout: 10 * time.Millisecond}, logger)
+
+	require.NoError(t, m.Handle(server.Message{Data: []byte("error")}))
+
+	select {
+	case msg := <-msgs:
+		require.Equal(t, "error", msg)
+	case <-time.After(time.Second):
+		t.Fatal("message was not flushed after timeout")
+	}
+}
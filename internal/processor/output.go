@@ -0,0 +1,168 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/api"
+)
+
+type (
+	// Output is an interface for a destination processed Log entries are sent to.
+	Output interface {
+		Send(ctx context.Context, logs []api.Log) error
+		Close(ctx context.Context) error
+	}
+
+	// ReceiverOutput sends Log entries to the logs receiver.
+	ReceiverOutput struct {
+		client *api.Client
+	}
+
+	// WriterOutput writes Log entries to io.Writer as newline-delimited JSON,
+	// e.g. to a local file or stdout for debugging.
+	WriterOutput struct {
+		mu sync.Mutex
+		w  io.WriteCloser
+	}
+
+	// RetryableError is an error of a failed send that may succeed later.
+	RetryableError struct {
+		Err error
+		// After is the delay requested by the server before retrying, if any.
+		After time.Duration
+		// Overload is true if the server explicitly asked to slow down.
+		Overload bool
+	}
+
+	// RejectedError is an error of a send some entries of which have been rejected by the receiver.
+	// Other entries have been stored, so they must not be sent again.
+	RejectedError struct {
+		Rejected []api.RejectedEntry
+	}
+)
+
+// NewReceiverOutput returns a new instance of ReceiverOutput.
+func NewReceiverOutput(client *api.Client) ReceiverOutput {
+	return ReceiverOutput{client: client}
+}
+
+// Send posts Log entries to the receiver.
+// Network errors and 429, 502, 503 and 504 responses are returned as RetryableError,
+// and entries rejected by the receiver as RejectedError.
+func (o ReceiverOutput) Send(ctx context.Context, logs []api.Log) error {
+	resp, err := o.client.PostLog(ctx, logs)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("sending entries to receiver: %w", err)
+		}
+
+		return &RetryableError{Err: fmt.Errorf("sending entries to receiver: %w", err)}
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusAccepted:
+		var result api.IngestResult
+
+		// Receivers not reporting results store all entries.
+		if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && len(result.Rejected) > 0 {
+			return &RejectedError{Rejected: result.Rejected}
+		}
+
+		return nil
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return &RetryableError{
+			Err:      fmt.Errorf("server responded with status code: %d", resp.StatusCode),
+			After:    retryAfter(resp.Header.Get("Retry-After")),
+			Overload: resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable,
+		}
+	default:
+		return fmt.Errorf("server responded with unsuccessful status code: %d", resp.StatusCode)
+	}
+}
+
+// Ping checks that the receiver is reachable and healthy.
+func (o ReceiverOutput) Ping(ctx context.Context) error {
+	resp, err := o.client.Health(ctx)
+	if err != nil {
+		return fmt.Errorf("checking receiver health: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("receiver health check responded with status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Close does nothing as the receiver client doesn't hold any resources.
+func (ReceiverOutput) Close(context.Context) error {
+	return nil
+}
+
+// NewWriterOutput returns a new instance of WriterOutput.
+func NewWriterOutput(w io.WriteCloser) *WriterOutput {
+	return &WriterOutput{w: w}
+}
+
+// Send writes Log entries, one JSON object per line.
+func (o *WriterOutput) Send(_ context.Context, logs []api.Log) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	enc := json.NewEncoder(o.w)
+
+	for _, log := range logs {
+		if err := enc.Encode(log); err != nil {
+			return fmt.Errorf("writing log entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes the underlying writer.
+func (o *WriterOutput) Close(context.Context) error {
+	return o.w.Close()
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("receiver rejected %d entries, e.g. entries[%d]: %s", len(e.Rejected), e.Rejected[0].Index, e.Rejected[0].Reason)
+}
+
+// retryAfter parses the Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+
+	return 0
+}
@@ -0,0 +1,322 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/metrics"
+)
+
+type testOutput struct {
+	mu      sync.Mutex
+	batches [][]api.Log
+	fails   int
+	err     error
+	closed  bool
+}
+
+func (o *testOutput) Send(_ context.Context, logs []api.Log) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.fails > 0 {
+		o.fails--
+		return o.err
+	}
+
+	o.batches = append(o.batches, logs)
+
+	return nil
+}
+
+func (o *testOutput) Close(context.Context) error {
+	o.closed = true
+	return nil
+}
+
+func (o *testOutput) ids() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var ids []string
+
+	for _, batch := range o.batches {
+		for _, log := range batch {
+			ids = append(ids, log.Id)
+		}
+	}
+
+	return ids
+}
+
+func TestRouter_Send(t *testing.T) {
+	logs := []api.Log{
+		{Id: "1", Severity: "Error", Attributes: map[string]any{"tags": []any{"security", "auth"}}},
+		{Id: "2", Severity: "Error", Attributes: map[string]any{"service": "payments"}},
+		{Id: "3", Severity: "Information"},
+	}
+
+	tests := map[string]struct {
+		giveConditions [][]Condition
+		giveFinal      []bool
+		wantIDs        [][]string
+	}{
+		"fan out to all outputs": {
+			giveConditions: [][]Condition{nil, nil},
+			giveFinal:      []bool{false, false},
+			wantIDs:        [][]string{{"1", "2", "3"}, {"1", "2", "3"}},
+		},
+		"route by list attribute exclusively": {
+			giveConditions: [][]Condition{{{Field: "attributes.tags", Value: "security"}}, nil},
+			giveFinal:      []bool{true, false},
+			wantIDs:        [][]string{{"1"}, {"2", "3"}},
+		},
+		"route by severity and attribute": {
+			giveConditions: [][]Condition{{{Field: "severity", Value: "error"}, {Field: "service", Value: "payments"}}},
+			giveFinal:      []bool{false},
+			wantIDs:        [][]string{{"2"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			outputs := make([]*testOutput, len(test.giveConditions))
+			routes := make([]Route, len(test.giveConditions))
+
+			for i := range routes {
+				outputs[i] = &testOutput{}
+				routes[i] = Route{Conditions: test.giveConditions[i], Final: test.giveFinal[i], Output: outputs[i]}
+			}
+
+			require.NoError(t, NewRouter(routes...).Send(context.Background(), logs))
+
+			for i, out := range outputs {
+				require.Equal(t, test.wantIDs[i], out.ids())
+			}
+		})
+	}
+}
+
+func TestBatchOutput_Send(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	unavailable := &RetryableError{Err: errors.New("unavailable")}
+	overloaded := &RetryableError{Err: errors.New("overloaded"), After: time.Millisecond, Overload: true}
+
+	tests := map[string]struct {
+		giveOpts    BatchOptions
+		giveFails   int
+		giveErr     error
+		giveLogs    []api.Log
+		wantSent    [][]api.Log
+		wantStored  uint64
+		wantRetried uint64
+		wantDropped uint64
+	}{
+		"send complete batches and flush the rest on close": {
+			giveOpts: BatchOptions{Size: 2, FlushInterval: time.Hour},
+			giveLogs: []api.Log{{Id: "1"}, {Id: "2"}, {Id: "3"}},
+			wantSent: [][]api.Log{{{Id: "1"}, {Id: "2"}}, {{Id: "3"}}},
+		},
+		"retry failed batch": {
+			giveOpts:    BatchOptions{Size: 1, FlushInterval: time.Hour, MaxRetries: 2},
+			giveFails:   2,
+			giveErr:     unavailable,
+			giveLogs:    []api.Log{{Id: "1"}},
+			wantSent:    [][]api.Log{{{Id: "1"}}},
+			wantRetried: 2,
+		},
+		"drop batch after retries": {
+			giveOpts:    BatchOptions{Size: 1, FlushInterval: time.Hour, MaxRetries: 1},
+			giveFails:   2,
+			giveErr:     unavailable,
+			giveLogs:    []api.Log{{Id: "1"}, {Id: "2"}},
+			wantSent:    [][]api.Log{{{Id: "2"}}},
+			wantRetried: 1,
+			wantDropped: 1,
+		},
+		"drop batch after permanent failure": {
+			giveOpts:    BatchOptions{Size: 1, FlushInterval: time.Hour, MaxRetries: 3},
+			giveFails:   1,
+			giveErr:     errors.New("bad request"),
+			giveLogs:    []api.Log{{Id: "1"}, {Id: "2"}},
+			wantSent:    [][]api.Log{{{Id: "2"}}},
+			wantDropped: 1,
+		},
+		"drop rejected entries only": {
+			giveOpts:    BatchOptions{Size: 3, FlushInterval: time.Hour, MaxRetries: 3},
+			giveFails:   1,
+			giveErr:     &RejectedError{Rejected: []api.RejectedEntry{{Index: 2, Reason: "quota exceeded"}}},
+			giveLogs:    []api.Log{{Id: "1"}, {Id: "2"}, {Id: "3"}},
+			wantSent:    nil,
+			wantStored:  2,
+			wantDropped: 1,
+		},
+		"ignore rejected entries out of batch": {
+			giveOpts:   BatchOptions{Size: 3, FlushInterval: time.Hour, MaxRetries: 3},
+			giveFails:  1,
+			giveErr:    &RejectedError{Rejected: []api.RejectedEntry{{Index: 5, Reason: "unknown"}}},
+			giveLogs:   []api.Log{{Id: "1"}, {Id: "2"}, {Id: "3"}},
+			wantSent:   nil,
+			wantStored: 3,
+		},
+		"retry overload beyond max retries": {
+			giveOpts:    BatchOptions{Size: 1, FlushInterval: time.Hour, MaxRetries: 1, MinRate: 1000, MaxRate: 1000},
+			giveFails:   3,
+			giveErr:     overloaded,
+			giveLogs:    []api.Log{{Id: "1"}},
+			wantSent:    [][]api.Log{{{Id: "1"}}},
+			wantRetried: 3,
+		},
+		"drop batch if overload outlasts max wait": {
+			giveOpts:    BatchOptions{Size: 1, FlushInterval: time.Hour, MaxOverloadWait: time.Minute},
+			giveFails:   1,
+			giveErr:     &RetryableError{Err: errors.New("overloaded"), After: time.Hour, Overload: true},
+			giveLogs:    []api.Log{{Id: "1"}, {Id: "2"}},
+			wantSent:    [][]api.Log{{{Id: "2"}}},
+			wantDropped: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := Metrics{Sent: &metrics.Counter{}, Retried: &metrics.Counter{}, Dropped: &metrics.Counter{}, Pending: &metrics.Gauge{}}
+			test.giveOpts.Metrics = m
+
+			out := &testOutput{fails: test.giveFails, err: test.giveErr}
+			batch := NewBatchOutput(out, test.giveOpts, logger)
+
+			for _, log := range test.giveLogs {
+				require.NoError(t, batch.Send(context.Background(), []api.Log{log}))
+			}
+
+			require.NoError(t, batch.Close(context.Background()))
+			require.Equal(t, test.wantSent, out.batches)
+
+			sent := test.wantStored
+			for _, b := range test.wantSent {
+				sent += uint64(len(b))
+			}
+
+			require.Equal(t, sent, m.Sent.Value())
+			require.Equal(t, test.wantRetried, m.Retried.Value())
+			require.Equal(t, test.wantDropped, m.Dropped.Value())
+			require.Zero(t, m.Pending.Value())
+			require.True(t, out.closed)
+		})
+	}
+}
+
+func TestReceiverOutput_Send(t *testing.T) {
+	tests := map[string]struct {
+		giveStatus     int
+		giveRetryAfter string
+		giveBody       string
+		wantErr        bool
+		wantRetryable  *RetryableError
+		wantRejected   int
+	}{
+		"accepted": {
+			giveStatus: http.StatusAccepted,
+		},
+		"accepted with result": {
+			giveStatus: http.StatusAccepted,
+			giveBody:   `{"id":"a","accepted":1,"duplicates":["1"],"rejected":[]}`,
+		},
+		"accepted with rejected entries": {
+			giveStatus:   http.StatusAccepted,
+			giveBody:     `{"id":"a","accepted":0,"duplicates":[],"rejected":[{"index":0,"reason":"quota exceeded"}]}`,
+			wantErr:      true,
+			wantRejected: 1,
+		},
+		"overloaded with retry after": {
+			giveStatus:     http.StatusTooManyRequests,
+			giveRetryAfter: "3",
+			wantErr:        true,
+			wantRetryable:  &RetryableError{After: 3 * time.Second, Overload: true},
+		},
+		"gateway timeout": {
+			giveStatus:    http.StatusGatewayTimeout,
+			wantErr:       true,
+			wantRetryable: &RetryableError{},
+		},
+		"permanent bad request": {
+			giveStatus: http.StatusBadRequest,
+			wantErr:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if test.giveRetryAfter != "" {
+					w.Header().Set("Retry-After", test.giveRetryAfter)
+				}
+
+				w.WriteHeader(test.giveStatus)
+				_, _ = io.WriteString(w, test.giveBody)
+			}))
+			defer srv.Close()
+
+			client, err := api.NewClient(srv.URL)
+			require.NoError(t, err)
+
+			err = NewReceiverOutput(client).Send(context.Background(), []api.Log{{Id: "1"}})
+
+			if !test.wantErr {
+				require.NoError(t, err)
+				return
+			}
+
+			var (
+				retryable *RetryableError
+				rejected  *RejectedError
+			)
+
+			require.Error(t, err)
+			require.Equal(t, test.wantRetryable != nil, errors.As(err, &retryable))
+
+			if test.wantRejected > 0 {
+				require.ErrorAs(t, err, &rejected)
+				require.Len(t, rejected.Rejected, test.wantRejected)
+			}
+
+			if test.wantRetryable != nil {
+				require.Equal(t, test.wantRetryable.After, retryable.After)
+				require.Equal(t, test.wantRetryable.Overload, retryable.Overload)
+			}
+		})
+	}
+}
+
+func TestAIMD(t *testing.T) {
+	rate := NewAIMD(1, 100)
+
+	rate.Overload()
+	require.InDelta(t, 50, rate.Rate(), 0.001)
+
+	rate.Success()
+	require.InDelta(t, 51, rate.Rate(), 0.001)
+
+	for range 10 {
+		rate.Overload()
+	}
+
+	require.InDelta(t, 1, rate.Rate(), 0.001)
+}
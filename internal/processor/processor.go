@@ -3,9 +3,9 @@ package processor
 import (
 	"context"
 	"fmt"
-	"net/http"
 
 	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/metrics"
 	"github.com/dyptan-io/log-management/v2/internal/platform/server"
 )
 
@@ -15,36 +15,72 @@ type (
 		Decode(b []byte) (api.Log, error)
 	}
 
-	// Processor is a struct that processes and sends log entries to receiver.
+	// Transformer is an interface for a pipeline stage that modifies decoded
+	// Log entries before they are sent. It returns false if the entry must be dropped.
+	Transformer interface {
+		Transform(log api.Log) (api.Log, bool)
+	}
+
+	// Metrics are counters of entries passing through the pipeline, nil ones are not updated.
+	Metrics struct {
+		Decoded *metrics.Counter
+		// Invalid counts entries that can't be decoded.
+		Invalid *metrics.Counter
+		// Dropped counts entries dropped by transformers or after failed sends.
+		Dropped *metrics.Counter
+		Sent    *metrics.Counter
+		Retried *metrics.Counter
+		// Pending is the number of entries waiting to be sent by BatchOutput.
+		Pending *metrics.Gauge
+	}
+
+	// Processor is a struct that processes and sends log entries to the output.
 	Processor struct {
-		decoder SourceDecoder
-		client  *api.Client
+		decoder      SourceDecoder
+		transformers []Transformer
+		output       Output
+		metrics      Metrics
 	}
 )
 
-// New returns a new instance of Processor.
-func New(encoder SourceDecoder, client *api.Client) Processor {
+// New returns a new instance of Processor. Transformers are applied in the given order.
+func New(encoder SourceDecoder, output Output, transformers ...Transformer) Processor {
 	return Processor{
-		decoder: encoder,
-		client:  client,
+		decoder:      encoder,
+		transformers: transformers,
+		output:       output,
 	}
 }
 
-// Process decodes raw log entries and sends them to logs receiver.
+// WithMetrics returns a copy of Processor that updates the given metrics.
+func (p Processor) WithMetrics(m Metrics) Processor {
+	p.metrics = m
+
+	return p
+}
+
+// Process decodes raw log entries and sends them to the output.
 func (p Processor) Process(m server.Message) error {
 	log, err := p.decoder.Decode(m.Data)
 	if err != nil {
+		p.metrics.Invalid.Inc()
 		return fmt.Errorf("decodig raw log entry: %w", err)
 	}
 
-	// For optimal performance, logs need to be sent in batches.
-	resp, err := p.client.PostLog(context.Background(), []api.Log{log})
-	if err != nil {
-		return fmt.Errorf("sending entry to receiver: %w", err)
+	p.metrics.Decoded.Inc()
+
+	for _, t := range p.transformers {
+		var keep bool
+
+		if log, keep = t.Transform(log); !keep {
+			p.metrics.Dropped.Inc()
+			return nil
+		}
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("server responded with unsuccessful status code: %d", resp.StatusCode)
+	// Outputs are expected to batch entries for optimal performance.
+	if err := p.output.Send(context.Background(), []api.Log{log}); err != nil {
+		return fmt.Errorf("sending log entry: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,271 @@
+package processor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"github.com/dyptan-io/log-management/v2/api"
+)
+
+// Redaction modes supported by Redactor.
+const (
+	// RedactMask replaces sensitive values with a placeholder.
+	RedactMask RedactMode = "mask"
+	// RedactHash replaces sensitive values with a keyed hash, so that redacted
+	// values can still be joined across entries.
+	RedactHash RedactMode = "hash"
+	// RedactDrop removes the whole field containing a sensitive value.
+	RedactDrop RedactMode = "drop"
+)
+
+var (
+	// ErrUnknownDetector is an error when the detector is not one of built-in ones.
+	ErrUnknownDetector = errors.New("unknown detector")
+	// ErrUnknownRedactMode is an error when the redaction mode is not supported.
+	ErrUnknownRedactMode = errors.New("unknown redaction mode")
+	// ErrMissingRedactKey is an error when the hash mode is used without a key.
+	ErrMissingRedactKey = errors.New("missing redaction key")
+)
+
+// builtinDetectors are ordered so that broader detectors (e.g. bearer tokens)
+// run before narrower ones they may contain (e.g. JWT).
+var builtinDetectors = []Detector{
+	{
+		Name:    "bearer",
+		Pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+	},
+	{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	},
+	{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	},
+	{
+		Name:     "card",
+		Pattern:  regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Validate: isCardNumber,
+	},
+	{
+		Name: "ipv6",
+		// Whole tokens of word characters and colons are validated, so that parts of
+		// e.g. Cache::add or a:b:c aren't taken for addresses.
+		Pattern:  regexp.MustCompile(`[0-9A-Za-z_:]*:[0-9A-Za-z_:]*(?:\.\d+)*`),
+		Validate: isIPv6,
+	},
+	{
+		Name:     "ipv4",
+		Pattern:  regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`),
+		Validate: isIPv4,
+	},
+}
+
+type (
+	// RedactMode defines how Redactor handles detected sensitive values.
+	RedactMode string
+
+	// Detector is a rule that finds sensitive values in a string.
+	Detector struct {
+		// Name is used in the masked placeholders.
+		Name string
+		// Pattern finds candidates of sensitive values.
+		Pattern *regexp.Regexp
+		// Validate is an optional check to filter out false positive candidates.
+		Validate func(string) bool
+	}
+
+	// Redactor is a Transformer that masks, hashes or drops sensitive values found
+	// in Log entry message and string attributes.
+	Redactor struct {
+		detectors []Detector
+		mode      RedactMode
+		key       []byte
+	}
+)
+
+// Detectors returns built-in detectors by their names. All built-in detectors
+// are returned if no names are given.
+func Detectors(names ...string) ([]Detector, error) {
+	if len(names) == 0 {
+		return builtinDetectors, nil
+	}
+
+	var res []Detector
+
+	for _, d := range builtinDetectors {
+		for _, name := range names {
+			if d.Name == name {
+				res = append(res, d)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if !containsDetector(res, name) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownDetector, name)
+		}
+	}
+
+	return res, nil
+}
+
+// NewDetector returns a custom Detector for a given regular expression.
+func NewDetector(name, expr string) (Detector, error) {
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return Detector{}, fmt.Errorf("compiling %q detector: %w", name, err)
+	}
+
+	return Detector{Name: name, Pattern: pattern}, nil
+}
+
+// NewRedactor returns a new instance of Redactor. The key is required for the RedactHash mode.
+func NewRedactor(mode RedactMode, key []byte, detectors ...Detector) (Redactor, error) {
+	switch mode {
+	case RedactMask, RedactDrop:
+	case RedactHash:
+		if len(key) == 0 {
+			return Redactor{}, ErrMissingRedactKey
+		}
+	default:
+		return Redactor{}, fmt.Errorf("%w: %q", ErrUnknownRedactMode, mode)
+	}
+
+	return Redactor{
+		detectors: detectors,
+		mode:      mode,
+		key:       key,
+	}, nil
+}
+
+// Transform redacts the message and all string values in attributes, including nested ones.
+func (r Redactor) Transform(log api.Log) (api.Log, bool) {
+	if msg, ok := r.redact(log.Message); ok {
+		log.Message = msg
+	} else {
+		log.Message = ""
+	}
+
+	if log.Attributes != nil {
+		log.Attributes = r.redactMap(log.Attributes)
+	}
+
+	return log, true
+}
+
+func (r Redactor) redactValue(v any) (any, bool) {
+	switch v := v.(type) {
+	case string:
+		return r.redact(v)
+	case map[string]any:
+		return r.redactMap(v), true
+	case []any:
+		res := make([]any, 0, len(v))
+
+		for _, item := range v {
+			if item, ok := r.redactValue(item); ok {
+				res = append(res, item)
+			}
+		}
+
+		return res, true
+	default:
+		return v, true
+	}
+}
+
+func (r Redactor) redactMap(m map[string]any) map[string]any {
+	for k, v := range m {
+		if v, ok := r.redactValue(v); ok {
+			m[k] = v
+		} else {
+			delete(m, k)
+		}
+	}
+
+	return m
+}
+
+// redact returns the redacted string, or false if the value must be dropped.
+func (r Redactor) redact(s string) (string, bool) {
+	for _, d := range r.detectors {
+		var found bool
+
+		s = d.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if d.Validate != nil && !d.Validate(match) {
+				return match
+			}
+
+			found = true
+
+			return r.replacement(d.Name, match)
+		})
+
+		if found && r.mode == RedactDrop {
+			return "", false
+		}
+	}
+
+	return s, true
+}
+
+func (r Redactor) replacement(name, match string) string {
+	if r.mode != RedactHash {
+		return "[REDACTED:" + name + "]"
+	}
+
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(match))
+
+	return "[" + name + ":" + hex.EncodeToString(mac.Sum(nil))[:16] + "]"
+}
+
+func containsDetector(detectors []Detector, name string) bool {
+	for _, d := range detectors {
+		if d.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isCardNumber verifies the payment card number with the Luhn checksum.
+func isCardNumber(s string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	var sum int
+
+	for i := range len(digits) {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+	}
+
+	return sum%10 == 0
+}
+
+func isIPv4(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is4()
+}
+
+func isIPv6(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is6() && strings.ContainsAny(s, "0123456789abcdefABCDEF")
+}
@@ -0,0 +1,137 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dyptan-io/log-management/v2/api"
+)
+
+type (
+	// Condition matches Log entries whose field equals the value. The field is one of
+	// "id", "severity", "message" or an attribute name, optionally prefixed with
+	// "attributes.". List attributes match if any of their items equals the value.
+	Condition struct {
+		Field string
+		Value string
+	}
+
+	// Route sends Log entries matching all its conditions to the Output.
+	Route struct {
+		Conditions []Condition
+		// Final stops evaluation of subsequent routes for matched entries.
+		Final  bool
+		Output Output
+	}
+
+	// Router is an Output that fans Log entries out to the outputs of matching routes.
+	// Routes are evaluated in order and an entry is sent to every matching route until
+	// a final one.
+	Router struct {
+		routes []Route
+	}
+)
+
+// NewRouter returns a new instance of Router.
+func NewRouter(routes ...Route) Router {
+	return Router{routes: routes}
+}
+
+// ParseCondition parses a condition in field:value form.
+func ParseCondition(s string) (Condition, error) {
+	field, value, ok := strings.Cut(s, ":")
+	if !ok || field == "" {
+		return Condition{}, fmt.Errorf("expected field:value condition, got %q", s)
+	}
+
+	return Condition{Field: field, Value: value}, nil
+}
+
+// Match reports whether the Log entry satisfies the condition.
+func (c Condition) Match(log api.Log) bool {
+	switch c.Field {
+	case "id":
+		return log.Id == c.Value
+	case "severity":
+		return strings.EqualFold(log.Severity, c.Value)
+	case "message":
+		return log.Message == c.Value
+	}
+
+	v, ok := log.Attributes[strings.TrimPrefix(c.Field, "attributes.")]
+	if !ok {
+		return false
+	}
+
+	if items, ok := v.([]any); ok {
+		for _, item := range items {
+			if fmt.Sprint(item) == c.Value {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return fmt.Sprint(v) == c.Value
+}
+
+// Match reports whether the Log entry satisfies all route conditions.
+func (r Route) Match(log api.Log) bool {
+	for _, c := range r.Conditions {
+		if !c.Match(log) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Send groups Log entries by matching routes and sends them to route outputs.
+// Entries not matching any route are discarded.
+func (r Router) Send(ctx context.Context, logs []api.Log) error {
+	routed := make([][]api.Log, len(r.routes))
+
+	for _, log := range logs {
+		for i, route := range r.routes {
+			if !route.Match(log) {
+				continue
+			}
+
+			routed[i] = append(routed[i], log)
+
+			if route.Final {
+				break
+			}
+		}
+	}
+
+	var errs []error
+
+	for i, logs := range routed {
+		if len(logs) == 0 {
+			continue
+		}
+
+		if err := r.routes[i].Output.Send(ctx, logs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close closes outputs of all routes.
+func (r Router) Close(ctx context.Context) error {
+	var errs []error
+
+	for _, route := range r.routes {
+		if err := route.Output.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
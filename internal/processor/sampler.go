@@ -0,0 +1,171 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/ratelimit"
+)
+
+const (
+	// SampleRateAttribute is an attribute stamped on sampled entries with the rate they were
+	// kept at, so that the original counts can be extrapolated.
+	SampleRateAttribute = "sample_rate"
+	// limiterPruneInterval is an interval of forgetting rate limits of idle keys.
+	limiterPruneInterval = time.Minute
+)
+
+// Sampling modes supported by Sampler.
+const (
+	// SampleRandom keeps entries with a given probability.
+	SampleRandom SampleMode = "random"
+	// SampleDeterministic keeps entries based on the hash of their IDs, so the same
+	// entry is always either kept or dropped, e.g. by different shippers.
+	SampleDeterministic SampleMode = "deterministic"
+)
+
+var (
+	// ErrUnknownSampleMode is an error when the sampling mode is not supported.
+	ErrUnknownSampleMode = errors.New("unknown sampling mode")
+	// ErrBadSampleRate is an error when the sampling rate is out of range.
+	ErrBadSampleRate = errors.New("sample rate must be between 0 and 1")
+)
+
+type (
+	// SampleMode defines how Sampler decides which entries to keep.
+	SampleMode string
+
+	// Sampler is a Transformer that keeps only a fraction of entries with rates
+	// configured per severity.
+	Sampler struct {
+		mode        SampleMode
+		defaultRate float64
+		rules       map[string]float64
+		random      func() float64
+	}
+
+	// RateLimiter is a Transformer that drops entries exceeding the rate limit.
+	RateLimiter struct {
+		limiter *ratelimit.Limiter
+		keyAttr string
+		// pruned is the time limits of idle keys were forgotten last, in Unix nanoseconds.
+		pruned *atomic.Int64
+	}
+)
+
+// NewSampler returns a new instance of Sampler. Rules map severities (case-insensitive)
+// to sampling rates, entries of other severities are sampled at the default rate.
+func NewSampler(mode SampleMode, defaultRate float64, rules map[string]float64) (Sampler, error) {
+	if mode != SampleRandom && mode != SampleDeterministic {
+		return Sampler{}, fmt.Errorf("%w: %q", ErrUnknownSampleMode, mode)
+	}
+
+	if !isRate(defaultRate) {
+		return Sampler{}, fmt.Errorf("%w: default rate %v", ErrBadSampleRate, defaultRate)
+	}
+
+	normalized := make(map[string]float64, len(rules))
+
+	for severity, rate := range rules {
+		if !isRate(rate) {
+			return Sampler{}, fmt.Errorf("%w: %q rate %v", ErrBadSampleRate, severity, rate)
+		}
+
+		normalized[strings.ToLower(severity)] = rate
+	}
+
+	return Sampler{
+		mode:        mode,
+		defaultRate: defaultRate,
+		rules:       normalized,
+		random:      rand.Float64,
+	}, nil
+}
+
+// Transform keeps the entry with the probability configured for its severity.
+func (s Sampler) Transform(log api.Log) (api.Log, bool) {
+	rate, ok := s.rules[strings.ToLower(log.Severity)]
+	if !ok {
+		rate = s.defaultRate
+	}
+
+	if rate >= 1 {
+		return log, true
+	}
+
+	if rate <= 0 || s.score(log) >= rate {
+		return log, false
+	}
+
+	if log.Attributes == nil {
+		log.Attributes = make(map[string]any)
+	}
+
+	log.Attributes[SampleRateAttribute] = rate
+
+	return log, true
+}
+
+// score returns a number in [0, 1) the sampling rate is compared against.
+func (s Sampler) score(log api.Log) float64 {
+	if s.mode == SampleRandom || log.Id == "" {
+		return s.random()
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(log.Id))
+
+	return float64(h.Sum64()) / (math.MaxUint64 + 1.0)
+}
+
+// NewRateLimiter returns a new instance of RateLimiter that allows rate entries per
+// second with bursts of up to burst entries. If keyAttr is set, the entries are limited
+// separately per value of that attribute, otherwise the limit applies to the whole pipeline.
+// Limits of attribute values that have been idle are forgotten periodically, so that the limiter
+// doesn't grow unbounded with short-living values, e.g. request IDs.
+func NewRateLimiter(rate float64, burst int, keyAttr string) RateLimiter {
+	r := RateLimiter{
+		limiter: ratelimit.New(rate, burst),
+		keyAttr: keyAttr,
+		pruned:  &atomic.Int64{},
+	}
+
+	r.pruned.Store(time.Now().UnixNano())
+
+	return r
+}
+
+// Transform drops the entry if the rate limit is exceeded.
+func (r RateLimiter) Transform(log api.Log) (api.Log, bool) {
+	var key string
+
+	if r.keyAttr != "" {
+		if v, ok := log.Attributes[r.keyAttr]; ok {
+			key = fmt.Sprint(v)
+		}
+
+		r.prune(time.Now())
+	}
+
+	return log, r.limiter.Allow(key)
+}
+
+// prune forgets limits of idle keys once per limiterPruneInterval.
+func (r RateLimiter) prune(now time.Time) {
+	last := r.pruned.Load()
+
+	if now.UnixNano()-last >= int64(limiterPruneInterval) && r.pruned.CompareAndSwap(last, now.UnixNano()) {
+		r.limiter.Prune()
+	}
+}
+
+func isRate(v float64) bool {
+	return v >= 0 && v <= 1
+}
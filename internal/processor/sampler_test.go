@@ -0,0 +1,126 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+)
+
+func TestSampler_Transform(t *testing.T) {
+	tests := map[string]struct {
+		giveMode   SampleMode
+		giveRules  map[string]float64
+		giveRandom float64
+		giveLog    api.Log
+		wantLog    api.Log
+		wantKeep   bool
+	}{
+		"keep all errors": {
+			giveMode:  SampleRandom,
+			giveRules: map[string]float64{"error": 1, "debug": 0.1},
+			giveLog:   api.Log{Severity: "Error"},
+			wantLog:   api.Log{Severity: "Error"},
+			wantKeep:  true,
+		},
+		"keep sampled debug with rate": {
+			giveMode:   SampleRandom,
+			giveRules:  map[string]float64{"error": 1, "debug": 0.1},
+			giveRandom: 0.05,
+			giveLog:    api.Log{Severity: "Debug"},
+			wantLog:    api.Log{Severity: "Debug", Attributes: map[string]any{SampleRateAttribute: 0.1}},
+			wantKeep:   true,
+		},
+		"drop sampled debug": {
+			giveMode:   SampleRandom,
+			giveRules:  map[string]float64{"error": 1, "debug": 0.1},
+			giveRandom: 0.5,
+			giveLog:    api.Log{Severity: "Debug"},
+			wantKeep:   false,
+		},
+		"drop by default rate": {
+			giveMode: SampleRandom,
+			giveLog:  api.Log{Severity: "Information"},
+			wantKeep: false,
+		},
+		"keep by ID hash": {
+			giveMode:   SampleDeterministic,
+			giveRules:  map[string]float64{"debug": 0.2},
+			giveRandom: 1,
+			giveLog:    api.Log{Id: "a5843dcb-9f21-4123-9c7c-688f0e8b88a7", Severity: "debug"},
+			wantLog: api.Log{
+				Id:         "a5843dcb-9f21-4123-9c7c-688f0e8b88a7",
+				Severity:   "debug",
+				Attributes: map[string]any{SampleRateAttribute: 0.2},
+			},
+			wantKeep: true,
+		},
+		"drop by ID hash": {
+			giveMode:   SampleDeterministic,
+			giveRules:  map[string]float64{"debug": 0.1},
+			giveRandom: 0,
+			giveLog:    api.Log{Id: "0d3f329c-3d20-4975-9beb-cf4425d3a138", Severity: "debug"},
+			wantKeep:   false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sampler, err := NewSampler(test.giveMode, 0, test.giveRules)
+			require.NoError(t, err)
+
+			sampler.random = func() float64 { return test.giveRandom }
+
+			log, keep := sampler.Transform(test.giveLog)
+
+			require.Equal(t, test.wantKeep, keep)
+
+			if keep {
+				require.Equal(t, test.wantLog, log)
+			}
+		})
+	}
+}
+
+func TestNewSampler(t *testing.T) {
+	_, err := NewSampler("always", 1, nil)
+	require.ErrorIs(t, err, ErrUnknownSampleMode)
+
+	_, err = NewSampler(SampleRandom, 1, map[string]float64{"debug": 10})
+	require.ErrorIs(t, err, ErrBadSampleRate)
+}
+
+func TestRateLimiter_Transform(t *testing.T) {
+	limiter := NewRateLimiter(0, 1, "service")
+
+	_, keep := limiter.Transform(api.Log{Attributes: map[string]any{"service": "api"}})
+	require.True(t, keep)
+
+	_, keep = limiter.Transform(api.Log{Attributes: map[string]any{"service": "api"}})
+	require.False(t, keep)
+
+	_, keep = limiter.Transform(api.Log{Attributes: map[string]any{"service": "db"}})
+	require.True(t, keep)
+	require.Equal(t, []string{"api", "db"}, limiter.limiter.Keys())
+}
+
+func TestRateLimiter_Prune(t *testing.T) {
+	limiter := NewRateLimiter(1000, 1, "request_id")
+
+	for _, id := range []string{"1", "2"} {
+		_, keep := limiter.Transform(api.Log{Attributes: map[string]any{"request_id": id}})
+		require.True(t, keep)
+	}
+
+	require.Equal(t, []string{"1", "2"}, limiter.limiter.Keys())
+
+	// Limits of idle keys are forgotten once the interval passes.
+	time.Sleep(10 * time.Millisecond)
+	limiter.prune(time.Now().Add(limiterPruneInterval))
+
+	require.Empty(t, limiter.limiter.Keys())
+}
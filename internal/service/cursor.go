@@ -0,0 +1,71 @@
+package service
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// NextCursorHeader is the header of ListLogs responses with the cursor of the next page.
+const NextCursorHeader = "X-Next-Cursor"
+
+// Sizes of ListLogs pages.
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+type (
+	// Cursor is a position in Log entries sorted by the time, right after the entry with the time
+	// and sequence number. It's given to clients as an opaque string to get the next page of entries.
+	Cursor struct {
+		Time       TimeField
+		Descending bool
+		At         time.Time
+		Sequence   int64
+	}
+
+	// cursorJSON is the encoded form of Cursor.
+	cursorJSON struct {
+		Time       TimeField `json:"t"`
+		Descending bool      `json:"d,omitempty"`
+		At         time.Time `json:"at"`
+		Sequence   int64     `json:"s"`
+	}
+)
+
+// ParseCursor returns the Cursor encoded by Cursor.String.
+func ParseCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: malformed cursor %q", ErrBadSearch, s)
+	}
+
+	var c cursorJSON
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, fmt.Errorf("%w: malformed cursor %q", ErrBadSearch, s)
+	}
+
+	if c.Time != EventTime && c.Time != ReceivedTime {
+		return Cursor{}, fmt.Errorf("%w: malformed cursor %q", ErrBadSearch, s)
+	}
+
+	return Cursor(c), nil
+}
+
+// cursorAt returns the Cursor right after the entry in the order of the search options.
+func cursorAt(opts SearchOptions, entry LogEntry) *Cursor {
+	field := cmp.Or(opts.Time, EventTime)
+
+	return &Cursor{Time: field, Descending: opts.Descending, At: field.of(entry), Sequence: entry.Sequence}
+}
+
+// String returns the Cursor encoded as a URL-safe string.
+func (c Cursor) String() string {
+	// Marshal never fails for the cursor fields.
+	data, _ := json.Marshal(cursorJSON(c))
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestParseCursor(t *testing.T) {
+	cursor := Cursor{Time: ReceivedTime, Descending: true, At: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), Sequence: 7}
+
+	tests := map[string]struct {
+		give       string
+		wantCursor Cursor
+		wantErr    error
+	}{
+		"encoded cursor": {
+			give:       cursor.String(),
+			wantCursor: cursor,
+		},
+		"not base64": {
+			give:    "cursor!",
+			wantErr: ErrBadSearch,
+		},
+		"not JSON": {
+			give:    "Y3Vyc29y",
+			wantErr: ErrBadSearch,
+		},
+		"unknown time": {
+			give:    Cursor{Time: "created"}.String(),
+			wantErr: ErrBadSearch,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseCursor(test.give)
+
+			require.ErrorIs(t, err, test.wantErr)
+			require.Equal(t, test.wantCursor, got)
+		})
+	}
+}
+
+func TestRepository_GetPage(t *testing.T) {
+	repo := NewRepository(storage.NewInMemory[LogEntry]())
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range 5 {
+		// Pairs of entries have the same timestamp and are sorted by sequence.
+		_, err := repo.Create(LogEntry{Id: strconv.Itoa(i), Timestamp: start.Add(time.Duration(i/2) * time.Minute)})
+		require.NoError(t, err)
+	}
+
+	tests := map[string]struct {
+		giveOpts  SearchOptions
+		wantPages [][]string
+	}{
+		"ascending": {
+			giveOpts:  SearchOptions{Limit: 2},
+			wantPages: [][]string{{"0", "1"}, {"2", "3"}, {"4"}},
+		},
+		"descending": {
+			giveOpts:  SearchOptions{Limit: 2, Descending: true},
+			wantPages: [][]string{{"4", "3"}, {"2", "1"}, {"0"}},
+		},
+		"exact pages": {
+			giveOpts:  SearchOptions{Limit: 5},
+			wantPages: [][]string{{"0", "1", "2", "3", "4"}},
+		},
+		"no limit": {
+			wantPages: [][]string{{"0", "1", "2", "3", "4"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := test.giveOpts
+
+			var pages [][]string
+
+			for {
+				page, err := repo.GetPage(opts)
+				require.NoError(t, err)
+
+				ids := make([]string, 0, len(page.Entries))
+				for _, entry := range page.Entries {
+					ids = append(ids, entry.Id)
+				}
+
+				pages = append(pages, ids)
+
+				if page.Next == nil {
+					break
+				}
+
+				opts.After = page.Next
+			}
+
+			require.Equal(t, test.wantPages, pages)
+		})
+	}
+}
+
+func TestRepository_GetPageConcurrentInserts(t *testing.T) {
+	repo := NewRepository(storage.NewInMemory[LogEntry]())
+	now := time.Now()
+
+	for _, id := range []string{"a", "b", "c"} {
+		_, err := repo.Create(LogEntry{Id: id, Timestamp: now})
+		require.NoError(t, err)
+	}
+
+	page, err := repo.GetPage(SearchOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+
+	// Entries inserted before the cursor don't shift the next page.
+	_, err = repo.Create(LogEntry{Id: "earlier", Timestamp: now.Add(-time.Hour)})
+	require.NoError(t, err)
+
+	next, err := repo.GetPage(SearchOptions{Limit: 2, After: page.Next})
+	require.NoError(t, err)
+	require.Len(t, next.Entries, 1)
+	require.Equal(t, "c", next.Entries[0].Id)
+	require.Nil(t, next.Next)
+
+	_, err = repo.GetPage(SearchOptions{Limit: 2, After: page.Next, Descending: true})
+	require.ErrorIs(t, err, ErrBadSearch)
+}
+
+func TestServer_ListLogsPages(t *testing.T) {
+	handler := api.Handler(NewServer(
+		NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{}, nil),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	))
+
+	entries := make([]string, 0, 5)
+	for i := range 5 {
+		entries = append(entries, `{"id":"`+strconv.Itoa(i)+`","message":"m","severity":"Info","timestamp":"2024-01-0`+
+			strconv.Itoa(i+1)+`T00:00:00Z","attributes":{}}`)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader("["+strings.Join(entries, ",")+"]")))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	var pages [][]string
+
+	for uri := "/v1/logs?order=desc&limit=2"; uri != ""; {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, uri, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var logs []api.Log
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &logs))
+
+		ids := make([]string, 0, len(logs))
+		for _, log := range logs {
+			ids = append(ids, log.Id)
+		}
+
+		pages = append(pages, ids)
+
+		uri = ""
+		if link := rec.Header().Get("Link"); link != "" {
+			require.True(t, strings.HasSuffix(link, `>; rel="next"`))
+			require.Contains(t, link, "cursor="+rec.Header().Get(NextCursorHeader))
+
+			uri = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		}
+	}
+
+	require.Equal(t, [][]string{{"4", "3"}, {"2", "1"}, {"0"}}, pages)
+
+	tests := map[string]struct {
+		giveQuery string
+	}{
+		"zero limit":      {giveQuery: "?limit=0"},
+		"too large limit": {giveQuery: "?limit=1001"},
+		"bad cursor":      {giveQuery: "?cursor=cursor!"},
+		"cursor of another order": {
+			giveQuery: "?order=desc&cursor=" + Cursor{Time: EventTime}.String(),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/logs"+test.giveQuery, nil))
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
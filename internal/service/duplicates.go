@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Duplicate policies of repositories.
+const (
+	// FirstWriteWins keeps stored entries and ignores duplicates.
+	FirstWriteWins DuplicatePolicy = "first-write-wins"
+	// LastWriteWins replaces stored entries with duplicates.
+	LastWriteWins DuplicatePolicy = "last-write-wins"
+	// ConflictError keeps stored entries, ignores identical duplicates and rejects different ones.
+	ConflictError DuplicatePolicy = "conflict-error"
+)
+
+var (
+	// ErrConflict is an error when a duplicate differs from the stored entry under ConflictError policy.
+	ErrConflict = errors.New("entry with the same ID and different content is already stored")
+	// ErrUnknownDuplicatePolicy is an error when the duplicate policy isn't supported.
+	ErrUnknownDuplicatePolicy = errors.New("unknown duplicate policy")
+)
+
+// DuplicatePolicy defines how entries with IDs that are already stored are handled.
+// Empty DuplicatePolicy is FirstWriteWins, so that only unique entries are stored.
+type DuplicatePolicy string
+
+// Validate checks that the policy is supported.
+func (p DuplicatePolicy) Validate() error {
+	switch p {
+	case "", FirstWriteWins, LastWriteWins, ConflictError:
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q", ErrUnknownDuplicatePolicy, p)
+}
+
+// sameEntry reports whether entries have the same content.
+func sameEntry(a, b LogEntry) bool {
+	if a.Id != b.Id || a.Message != b.Message || a.Severity != b.Severity || !a.Timestamp.Equal(b.Timestamp) {
+		return false
+	}
+
+	// Missing and empty attributes are the same.
+	if len(a.Attributes) == 0 && len(b.Attributes) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a.Attributes, b.Attributes)
+}
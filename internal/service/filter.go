@@ -0,0 +1,231 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Operators of attribute filters.
+const (
+	// AttributeExists matches entries with the attribute.
+	AttributeExists AttributeOp = ""
+	// AttributeMissing matches entries without the attribute.
+	AttributeMissing AttributeOp = "!"
+	// AttributeEqual matches entries with the attribute equal to the value, or with a list attribute containing it.
+	AttributeEqual AttributeOp = "="
+	// AttributeNotEqual matches entries that AttributeEqual doesn't match, including ones without the attribute.
+	AttributeNotEqual AttributeOp = "!="
+	// Numeric comparisons match entries with numeric attributes, or list attributes with any matching number.
+	AttributeLess           AttributeOp = "<"
+	AttributeLessOrEqual    AttributeOp = "<="
+	AttributeGreater        AttributeOp = ">"
+	AttributeGreaterOrEqual AttributeOp = ">="
+)
+
+type (
+	// AttributeOp is an operator of AttributeFilter.
+	AttributeOp string
+
+	// AttributeFilter is a predicate on an attribute of Log entries. The attribute is named as in Policy.Match,
+	// with dots separating names of nested attributes.
+	AttributeFilter struct {
+		Name  string
+		Op    AttributeOp
+		Value string
+
+		number float64
+	}
+)
+
+// severityLevels are levels of known severities, so that entries can be filtered by the minimal level.
+var severityLevels = map[string]int{
+	"trace":       0,
+	"debug":       1,
+	"info":        2,
+	"information": 2,
+	"notice":      3,
+	"warn":        4,
+	"warning":     4,
+	"error":       5,
+	"err":         5,
+	"critical":    6,
+	"crit":        6,
+	"fatal":       6,
+	"alert":       7,
+	"emergency":   8,
+	"emerg":       8,
+}
+
+// ParseAttributeFilter returns the AttributeFilter given as "name" (the attribute exists), "!name" (it's missing),
+// or "name<op>value", where op is one of =, !=, <, <=, > and >=, e.g. "http.status>=500".
+func ParseAttributeFilter(s string) (AttributeFilter, error) {
+	if name, ok := strings.CutPrefix(s, string(AttributeMissing)); ok {
+		if name == "" || strings.ContainsAny(name, "=<>!") {
+			return AttributeFilter{}, fmt.Errorf("%w: malformed attribute filter %q", ErrBadSearch, s)
+		}
+
+		return AttributeFilter{Name: name, Op: AttributeMissing}, nil
+	}
+
+	i := strings.IndexAny(s, "=<>!")
+	if i < 0 {
+		return NewAttributeFilter(s, AttributeExists, "")
+	}
+
+	op := AttributeOp(s[i : i+1])
+	if rest := s[i+1:]; strings.HasPrefix(rest, "=") && op != AttributeEqual {
+		op += "="
+	}
+
+	if op == AttributeMissing {
+		return AttributeFilter{}, fmt.Errorf("%w: malformed attribute filter %q", ErrBadSearch, s)
+	}
+
+	return NewAttributeFilter(s[:i], op, s[i+len(op):])
+}
+
+// NewAttributeFilter returns an AttributeFilter of the attribute name, operator and value.
+func NewAttributeFilter(name string, op AttributeOp, value string) (AttributeFilter, error) {
+	f := AttributeFilter{Name: name, Op: op, Value: value}
+
+	if name == "" {
+		return AttributeFilter{}, fmt.Errorf("%w: attribute filter without a name", ErrBadSearch)
+	}
+
+	switch op {
+	case AttributeExists, AttributeMissing, AttributeEqual, AttributeNotEqual:
+	case AttributeLess, AttributeLessOrEqual, AttributeGreater, AttributeGreaterOrEqual:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return AttributeFilter{}, fmt.Errorf("%w: attribute %q is compared to a non-numeric value %q", ErrBadSearch, name, value)
+		}
+
+		f.number = n
+	default:
+		return AttributeFilter{}, fmt.Errorf("%w: unknown attribute operator %q", ErrBadSearch, op)
+	}
+
+	return f, nil
+}
+
+// Match reports whether the attributes match the filter.
+func (f AttributeFilter) Match(attrs map[string]any) bool {
+	v, ok := lookupAttribute(attrs, f.Name)
+
+	switch f.Op {
+	case AttributeExists:
+		return ok
+	case AttributeMissing:
+		return !ok
+	case AttributeEqual:
+		return ok && matchValue(v, f.Value)
+	case AttributeNotEqual:
+		return !ok || !matchValue(v, f.Value)
+	}
+
+	items, isList := v.([]any)
+	if !isList {
+		items = []any{v}
+	}
+
+	for _, item := range items {
+		if n, isNumber := toNumber(item); isNumber && f.compare(n) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compare reports whether the number is in the relation of the operator to the filter value.
+func (f AttributeFilter) compare(n float64) bool {
+	switch f.Op {
+	case AttributeLess:
+		return n < f.number
+	case AttributeLessOrEqual:
+		return n <= f.number
+	case AttributeGreater:
+		return n > f.number
+	case AttributeGreaterOrEqual:
+		return n >= f.number
+	}
+
+	return false
+}
+
+// String returns the filter in the form parsed by ParseAttributeFilter.
+func (f AttributeFilter) String() string {
+	if f.Op == AttributeMissing {
+		return string(f.Op) + f.Name
+	}
+
+	return f.Name + string(f.Op) + f.Value
+}
+
+// filter returns the predicate of entries matching the severity, message, ID and attribute filters of the options.
+func (o SearchOptions) filter() (func(LogEntry) bool, error) {
+	minLevel := -1
+
+	if o.MinSeverity != "" {
+		level, ok := severityLevel(o.MinSeverity)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown severity %q", ErrBadSearch, o.MinSeverity)
+		}
+
+		minLevel = level
+	}
+
+	return func(entry LogEntry) bool {
+		if len(o.Severities) > 0 && !slices.ContainsFunc(o.Severities, func(s string) bool {
+			return strings.EqualFold(s, entry.Severity)
+		}) {
+			return false
+		}
+
+		if minLevel >= 0 {
+			if level, ok := severityLevel(entry.Severity); !ok || level < minLevel {
+				return false
+			}
+		}
+
+		if !strings.HasPrefix(entry.Id, o.IDPrefix) || !strings.Contains(entry.Message, o.Message) {
+			return false
+		}
+
+		if o.MessagePattern != nil && !o.MessagePattern.MatchString(entry.Message) {
+			return false
+		}
+
+		for _, f := range o.Attributes {
+			if !f.Match(entry.Attributes) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// severityLevel returns the level of the severity, compared case-insensitively.
+func severityLevel(severity string) (int, bool) {
+	level, ok := severityLevels[strings.ToLower(severity)]
+
+	return level, ok
+}
+
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+
+	return 0, false
+}
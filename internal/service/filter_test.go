@@ -0,0 +1,291 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestParseAttributeFilter(t *testing.T) {
+	tests := map[string]struct {
+		give       string
+		wantFilter AttributeFilter
+		wantErr    error
+	}{
+		"exists": {
+			give:       "user.id",
+			wantFilter: AttributeFilter{Name: "user.id", Op: AttributeExists},
+		},
+		"missing": {
+			give:       "!user.id",
+			wantFilter: AttributeFilter{Name: "user.id", Op: AttributeMissing},
+		},
+		"equal": {
+			give:       "service=api=v2",
+			wantFilter: AttributeFilter{Name: "service", Op: AttributeEqual, Value: "api=v2"},
+		},
+		"not equal": {
+			give:       "service!=api",
+			wantFilter: AttributeFilter{Name: "service", Op: AttributeNotEqual, Value: "api"},
+		},
+		"greater or equal": {
+			give:       "http.status>=500",
+			wantFilter: AttributeFilter{Name: "http.status", Op: AttributeGreaterOrEqual, Value: "500", number: 500},
+		},
+		"less": {
+			give:       "latency<0.5",
+			wantFilter: AttributeFilter{Name: "latency", Op: AttributeLess, Value: "0.5", number: 0.5},
+		},
+		"empty name": {
+			give:    "=api",
+			wantErr: ErrBadSearch,
+		},
+		"non-numeric comparison": {
+			give:    "service>api",
+			wantErr: ErrBadSearch,
+		},
+		"malformed missing": {
+			give:    "!service=api",
+			wantErr: ErrBadSearch,
+		},
+		"malformed operator": {
+			give:    "service!api",
+			wantErr: ErrBadSearch,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseAttributeFilter(test.give)
+
+			require.ErrorIs(t, err, test.wantErr)
+			require.Equal(t, test.wantFilter, got)
+
+			if test.wantErr == nil {
+				require.Equal(t, test.give, got.String())
+			}
+		})
+	}
+}
+
+func TestAttributeFilter_Match(t *testing.T) {
+	attrs := map[string]any{
+		"service": "api",
+		"tags":    []any{"a", "b"},
+		"codes":   []any{200.0, 503.0},
+		"http":    map[string]any{"status": 500.0},
+	}
+
+	tests := map[string]struct {
+		give string
+		want bool
+	}{
+		"exists":                   {give: "http.status", want: true},
+		"not exists":               {give: "user.id"},
+		"missing":                  {give: "!user.id", want: true},
+		"not missing":              {give: "!service"},
+		"equal":                    {give: "service=api", want: true},
+		"not equal":                {give: "service=web"},
+		"equal number":             {give: "http.status=500", want: true},
+		"list contains":            {give: "tags=b", want: true},
+		"different":                {give: "service!=web", want: true},
+		"different missing":        {give: "user.id!=u1", want: true},
+		"greater or equal":         {give: "http.status>=500", want: true},
+		"greater":                  {give: "http.status>500"},
+		"less":                     {give: "http.status<501", want: true},
+		"list with greater number": {give: "codes>500", want: true},
+		"compare string":           {give: "service<1"},
+		"compare missing":          {give: "user.id<1"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := ParseAttributeFilter(test.give)
+
+			require.NoError(t, err)
+			require.Equal(t, test.want, f.Match(attrs))
+		})
+	}
+}
+
+func TestRepository_GetFilters(t *testing.T) {
+	repo := NewRepository(storage.NewInMemory[LogEntry]())
+	now := time.Now()
+
+	for _, entry := range []LogEntry{
+		{Id: "api-1", Message: "request served", Severity: "INFO", Attributes: map[string]any{"service": "api", "status": 200.0}},
+		{Id: "api-2", Message: "request failed", Severity: "Error", Attributes: map[string]any{"service": "api", "status": 503.0}},
+		{Id: "web-1", Message: "slow request", Severity: "warning", Attributes: map[string]any{"service": "web"}},
+		{Id: "web-2", Message: "custom", Severity: "custom"},
+	} {
+		entry.Timestamp = now
+
+		_, err := repo.Create(entry)
+		require.NoError(t, err)
+	}
+
+	tests := map[string]struct {
+		giveOpts SearchOptions
+		wantIDs  []string
+		wantErr  error
+	}{
+		"severities": {
+			giveOpts: SearchOptions{Severities: []string{"info", "ERROR"}},
+			wantIDs:  []string{"api-1", "api-2"},
+		},
+		"minimal severity": {
+			giveOpts: SearchOptions{MinSeverity: "warn"},
+			wantIDs:  []string{"api-2", "web-1"},
+		},
+		"unknown minimal severity": {
+			giveOpts: SearchOptions{MinSeverity: "loud"},
+			wantErr:  ErrBadSearch,
+		},
+		"message": {
+			giveOpts: SearchOptions{Message: "request"},
+			wantIDs:  []string{"api-1", "api-2", "web-1"},
+		},
+		"message pattern": {
+			giveOpts: SearchOptions{MessagePattern: regexp.MustCompile(`^request (served|ok)$`)},
+			wantIDs:  []string{"api-1"},
+		},
+		"ID prefix": {
+			giveOpts: SearchOptions{IDPrefix: "web-"},
+			wantIDs:  []string{"web-1", "web-2"},
+		},
+		"attributes": {
+			giveOpts: SearchOptions{Attributes: []AttributeFilter{
+				{Name: "service", Op: AttributeEqual, Value: "api"},
+				{Name: "status", Op: AttributeGreaterOrEqual, Value: "500", number: 500},
+			}},
+			wantIDs: []string{"api-2"},
+		},
+		"all filters": {
+			giveOpts: SearchOptions{
+				MinSeverity: "info",
+				Message:     "request",
+				IDPrefix:    "web",
+				Attributes:  []AttributeFilter{{Name: "status", Op: AttributeMissing}},
+			},
+			wantIDs: []string{"web-1"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			entries, err := repo.Get(test.giveOpts)
+
+			require.ErrorIs(t, err, test.wantErr)
+
+			var ids []string
+			for _, entry := range entries {
+				ids = append(ids, entry.Id)
+			}
+
+			require.Equal(t, test.wantIDs, ids)
+		})
+	}
+}
+
+func TestServer_ListLogsFilters(t *testing.T) {
+	handler := api.Handler(NewServer(
+		NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{}, nil),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	))
+
+	body := `[{"id":"1","message":"request served","severity":"Info","timestamp":"2024-01-01T00:00:00Z","attributes":{"http":{"status":200}}},` +
+		`{"id":"2","message":"request failed","severity":"Error","timestamp":"2024-01-02T00:00:00Z","attributes":{"http":{"status":500}}}]`
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(body)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	tests := map[string]struct {
+		giveQuery  url.Values
+		wantStatus int
+		wantIDs    []string
+	}{
+		"no filters": {
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"1", "2"},
+		},
+		"severity": {
+			giveQuery:  url.Values{"severity": {"info", "debug"}},
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"1"},
+		},
+		"minimal severity": {
+			giveQuery:  url.Values{"min_severity": {"warning"}},
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"2"},
+		},
+		"message": {
+			giveQuery:  url.Values{"message": {"served"}, "message_regex": {"^request"}},
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"1"},
+		},
+		"ID prefix": {
+			giveQuery:  url.Values{"id_prefix": {"2"}},
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"2"},
+		},
+		"attributes": {
+			giveQuery:  url.Values{"attr": {"http.status", "http.status>=500"}},
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"2"},
+		},
+		"malformed attribute filter": {
+			giveQuery:  url.Values{"attr": {"http.status>=high"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		"malformed message regex": {
+			giveQuery:  url.Values{"message_regex": {"(request"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		"unknown minimal severity": {
+			giveQuery:  url.Values{"min_severity": {"loud"}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/logs?"+test.giveQuery.Encode(), nil))
+
+			require.Equal(t, test.wantStatus, rec.Code)
+
+			if test.wantStatus != http.StatusOK {
+				return
+			}
+
+			var logs []api.Log
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &logs))
+
+			ids := make([]string, 0, len(logs))
+			for _, log := range logs {
+				ids = append(ids, log.Id)
+			}
+
+			require.Equal(t, test.wantIDs, ids)
+		})
+	}
+}
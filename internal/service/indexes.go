@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/index"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+// severityIndex is the name of the storage index of Log entries by their lowercase severities.
+const severityIndex = "severity"
+
+// DefaultIndexedAttributes are attributes Log entries are indexed by, unless configured otherwise.
+var DefaultIndexedAttributes = []string{"service", "host", "trace_id"}
+
+// IndexLogs registers storage indexes of Log entries by their severities and by values of the attributes,
+// named as in AttributeFilter. Searches filtering by severities or by values of the attributes read entries
+// from the indexes, if they narrow down entries more than the time index does.
+func IndexLogs(store *storage.Namespaced[LogEntry], attributes []string) {
+	store.AddIndex(severityIndex, func(entry LogEntry) []string {
+		return []string{strings.ToLower(entry.Severity)}
+	})
+
+	for _, name := range attributes {
+		store.AddIndex(attributeIndex(name), func(entry LogEntry) []string {
+			return attributeValues(entry.Attributes, name)
+		})
+	}
+}
+
+// attributeIndex returns the name of the storage index of Log entries by values of the attribute.
+func attributeIndex(name string) string {
+	return "attributes." + name
+}
+
+// attributeValues returns values of the attribute that AttributeEqual filters match, if any.
+func attributeValues(attrs map[string]any, name string) []string {
+	v, ok := lookupAttribute(attrs, name)
+	if !ok {
+		return nil
+	}
+
+	items, isList := v.([]any)
+	if !isList {
+		return []string{fmt.Sprint(v)}
+	}
+
+	res := make([]string, 0, len(items))
+	for _, item := range items {
+		res = append(res, fmt.Sprint(item))
+	}
+
+	return res
+}
+
+// indexes are indexes of Log entries of a tenant by their sequence numbers, which are maintained
+// by the repository on every change of entries.
+type indexes struct {
+	// changes serializes changes of entries along with updates of the indexes, so that the indexes
+	// are updated in the order of changes and don't keep entries that have been replaced.
+	changes sync.Mutex
+
+	mu sync.RWMutex
+	// ids are IDs of indexed entries by their sequence numbers.
+	ids map[int64]storage.ID
+
+	text *index.Text
+	// event and received order entries by their timestamps and received times.
+	event    *index.Ordered
+	received *index.Ordered
+}
+
+func newIndexes() *indexes {
+	return &indexes{
+		ids:      make(map[int64]storage.ID),
+		text:     index.NewText(),
+		event:    index.NewOrdered(),
+		received: index.NewOrdered(),
+	}
+}
+
+// lock locks changes of entries until the returned func is called.
+func (i *indexes) lock() (unlock func()) {
+	if i == nil {
+		return func() {}
+	}
+
+	i.changes.Lock()
+
+	return i.changes.Unlock
+}
+
+// add indexes the stored entry.
+func (i *indexes) add(entry LogEntry) {
+	if i == nil {
+		return
+	}
+
+	i.mu.Lock()
+	i.ids[entry.Sequence] = entry.ID()
+	i.mu.Unlock()
+
+	i.text.Add(entry.Sequence, entry.Message)
+	i.event.Add(index.Key{Time: entry.Timestamp, Doc: entry.Sequence})
+	i.received.Add(index.Key{Time: entry.ReceivedAt, Doc: entry.Sequence})
+}
+
+// remove removes the entry that has been deleted or replaced.
+func (i *indexes) remove(entry LogEntry) {
+	if i == nil {
+		return
+	}
+
+	i.text.Remove(entry.Sequence)
+	i.event.Remove(index.Key{Time: entry.Timestamp, Doc: entry.Sequence})
+	i.received.Remove(index.Key{Time: entry.ReceivedAt, Doc: entry.Sequence})
+
+	i.mu.Lock()
+	delete(i.ids, entry.Sequence)
+	i.mu.Unlock()
+}
+
+// searchText returns entries with messages matching the query.
+func (i *indexes) searchText(db *storage.InMemory[LogEntry], q index.TextQuery, match storage.Matcher[LogEntry]) []LogEntry {
+	return i.resolve(db, i.text.Search(q), match)
+}
+
+// scan returns an iterator of matching entries in the time range of the options in their order, which reads
+// the time index as entries are consumed, until the context is done.
+func (i *indexes) scan(
+	ctx context.Context, db *storage.InMemory[LogEntry], opts SearchOptions, match storage.Matcher[LogEntry],
+) iter.Seq[LogEntry] {
+	ordered := i.event
+	if opts.Time == ReceivedTime {
+		ordered = i.received
+	}
+
+	var lo, hi *index.Key
+
+	if opts.From != nil {
+		lo = &index.Key{Time: *opts.From, Doc: math.MinInt64}
+	}
+
+	if opts.To != nil {
+		hi = &index.Key{Time: *opts.To, Doc: math.MaxInt64}
+	}
+
+	// Pages start at their cursors, which the matcher excludes.
+	if opts.After != nil {
+		after := index.Key{Time: opts.After.At, Doc: opts.After.Sequence}
+
+		if !opts.Descending && (lo == nil || after.Compare(*lo) > 0) {
+			lo = &after
+		}
+
+		if opts.Descending && (hi == nil || after.Compare(*hi) < 0) {
+			hi = &after
+		}
+	}
+
+	keys := ordered.Ascend(lo, hi)
+	if opts.Descending {
+		keys = ordered.Descend(hi, lo)
+	}
+
+	return func(yield func(LogEntry) bool) {
+		for k := range keys {
+			// Sparse matches may be far apart, so the context is checked on every key.
+			if ctx.Err() != nil {
+				return
+			}
+
+			if entry, ok := i.entry(db, k.Doc); ok && match(entry) && !yield(entry) {
+				return
+			}
+		}
+	}
+}
+
+// before returns entries with timestamps before the time.
+func (i *indexes) before(db *storage.InMemory[LogEntry], t time.Time) []LogEntry {
+	var seqs []int64
+
+	for k := range i.event.Ascend(nil, &index.Key{Time: t, Doc: math.MinInt64}) {
+		seqs = append(seqs, k.Doc)
+	}
+
+	return i.resolve(db, seqs, func(LogEntry) bool { return true })
+}
+
+// resolve returns matching entries of sequence numbers.
+func (i *indexes) resolve(db *storage.InMemory[LogEntry], seqs []int64, match storage.Matcher[LogEntry]) []LogEntry {
+	var res []LogEntry
+
+	for _, seq := range seqs {
+		if entry, ok := i.entry(db, seq); ok && match(entry) {
+			res = append(res, entry)
+		}
+	}
+
+	return res
+}
+
+// entry returns the stored entry of the sequence number, if any.
+func (i *indexes) entry(db *storage.InMemory[LogEntry], seq int64) (LogEntry, bool) {
+	i.mu.RLock()
+	id, ok := i.ids[seq]
+	i.mu.RUnlock()
+
+	if !ok {
+		return LogEntry{}, false
+	}
+
+	entry, err := db.Get(id)
+
+	// Entries replaced since they have been found have other sequence numbers.
+	return entry, err == nil && entry.Sequence == seq
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestRepository_GetText(t *testing.T) {
+	now := time.Now()
+
+	tenants := NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{Duplicates: LastWriteWins}, nil)
+	indexed := tenants.Repository(DefaultTenant)
+	scanned := NewRepository(storage.NewInMemory[LogEntry]()).withSettings(TenantSettings{Duplicates: LastWriteWins})
+
+	for _, repo := range []Repository{indexed, scanned} {
+		for _, entry := range []LogEntry{
+			{Id: "1", Message: "Payment timeout after 30s", Severity: "Error", Timestamp: now.Add(-2 * time.Hour)},
+			{Id: "2", Message: "connection reset by peer", Severity: "Warning", Timestamp: now},
+			{Id: "3", Message: "payment accepted", Severity: "Info", Timestamp: now},
+			{Id: "4", Message: "timed out waiting for connection", Severity: "Error", Timestamp: now},
+			{Id: "3", Message: "payment refused", Severity: "Info", Timestamp: now},
+		} {
+			_, err := repo.Create(entry)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 1, repo.DeleteBefore(now.Add(-time.Hour)))
+	}
+
+	tests := map[string]struct {
+		giveOpts SearchOptions
+		wantIDs  []string
+	}{
+		"term": {
+			giveOpts: SearchOptions{Text: "PAYMENT"},
+			wantIDs:  []string{"3"},
+		},
+		"replaced term": {
+			giveOpts: SearchOptions{Text: "accepted"},
+		},
+		"phrase": {
+			giveOpts: SearchOptions{Text: `"reset by"`},
+			wantIDs:  []string{"2"},
+		},
+		"prefix": {
+			giveOpts: SearchOptions{Text: "conn*"},
+			wantIDs:  []string{"2", "4"},
+		},
+		"text and filters": {
+			giveOpts: SearchOptions{Text: "conn*", MinSeverity: "error"},
+			wantIDs:  []string{"4"},
+		},
+		"no terms": {
+			giveOpts: SearchOptions{Text: "*"},
+			// The replaced entry is received last.
+			wantIDs: []string{"2", "4", "3"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, repo := range []Repository{indexed, scanned} {
+				entries, err := repo.Get(test.giveOpts)
+				require.NoError(t, err)
+
+				var ids []string
+				for _, entry := range entries {
+					ids = append(ids, entry.Id)
+				}
+
+				require.Equal(t, test.wantIDs, ids)
+			}
+		})
+	}
+}
+
+func TestRepository_GetTime(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tenants := NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{}, nil)
+	indexed := tenants.Repository(DefaultTenant)
+	scanned := NewRepository(storage.NewInMemory[LogEntry]())
+
+	for i := range 2000 {
+		// Timestamps are out of order and repeated, and received times are in order.
+		entry := LogEntry{Id: strconv.Itoa(i), Severity: "Info", Timestamp: start.Add(time.Duration(i*7%300) * time.Minute)}
+		if i%3 == 0 {
+			entry.Severity = "Error"
+		}
+
+		for _, repo := range []Repository{indexed, scanned} {
+			_, err := repo.Create(entry)
+			require.NoError(t, err)
+		}
+	}
+
+	deleted := scanned.DeleteBefore(start.Add(30 * time.Minute))
+
+	require.NotZero(t, deleted)
+	require.Equal(t, deleted, indexed.DeleteBefore(start.Add(30*time.Minute)))
+
+	from, to := start.Add(time.Hour), start.Add(2*time.Hour)
+
+	tests := map[string]SearchOptions{
+		"all entries":           {},
+		"time range":            {From: &from, To: &to},
+		"descending":            {From: &from, Descending: true},
+		"received time":         {To: &to, Time: ReceivedTime},
+		"received descending":   {Time: ReceivedTime, Descending: true},
+		"filtered with limit":   {From: &from, MinSeverity: "error", Limit: 50},
+		"descending with limit": {To: &to, Descending: true, Limit: 70},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// Pages of both repositories are the same, though received times of their entries differ.
+			wantOpts, gotOpts := opts, opts
+
+			for {
+				want, err := scanned.GetPage(wantOpts)
+				require.NoError(t, err)
+
+				got, err := indexed.GetPage(gotOpts)
+				require.NoError(t, err)
+
+				require.Equal(t, ids(want.Entries), ids(got.Entries))
+				require.Equal(t, want.Next == nil, got.Next == nil)
+
+				if got.Next == nil {
+					break
+				}
+
+				wantOpts.After, gotOpts.After = want.Next, got.Next
+			}
+		})
+	}
+}
+
+func ids(entries []LogEntry) []string {
+	res := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		res = append(res, entry.Id)
+	}
+
+	return res
+}
+
+func TestRepository_GetIndexed(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := storage.NewNamespaced[LogEntry]()
+	IndexLogs(store, DefaultIndexedAttributes)
+
+	tenants := NewTenants(store, TenantSettings{Duplicates: LastWriteWins}, nil)
+	indexed := tenants.Repository(DefaultTenant)
+	scanned := NewRepository(storage.NewInMemory[LogEntry]()).withSettings(TenantSettings{Duplicates: LastWriteWins})
+
+	severities := []string{"Info", "Warning", "ERROR", "Debug"}
+
+	for i := range 2000 {
+		entry := LogEntry{
+			Id:        strconv.Itoa(i % 1900),
+			Severity:  severities[i%len(severities)],
+			Timestamp: start.Add(time.Duration(i*7%300) * time.Minute),
+			Attributes: map[string]any{
+				"service": "svc-" + strconv.Itoa(i%5),
+				"host":    []any{"host-" + strconv.Itoa(i%3), "shared"},
+			},
+		}
+
+		if i%100 == 0 {
+			entry.Attributes["trace_id"] = "trace-" + strconv.Itoa(i%300)
+		}
+
+		for _, repo := range []Repository{indexed, scanned} {
+			_, err := repo.Create(entry)
+			require.NoError(t, err)
+		}
+	}
+
+	from := start.Add(time.Hour)
+	trace, err := NewAttributeFilter("trace_id", AttributeEqual, "trace-0")
+	require.NoError(t, err)
+	service, err := NewAttributeFilter("service", AttributeEqual, "svc-1")
+	require.NoError(t, err)
+	host, err := NewAttributeFilter("host", AttributeEqual, "host-2")
+	require.NoError(t, err)
+	notIndexed, err := NewAttributeFilter("region", AttributeEqual, "eu")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		giveOpts    SearchOptions
+		wantIndexed bool
+	}{
+		"severity": {
+			giveOpts:    SearchOptions{Severities: []string{"error", "debug"}},
+			wantIndexed: true,
+		},
+		"severity with limit": {
+			giveOpts: SearchOptions{Severities: []string{"error"}, Limit: 30},
+		},
+		"attribute": {
+			giveOpts:    SearchOptions{Attributes: []AttributeFilter{service}, Descending: true},
+			wantIndexed: true,
+		},
+		"list attribute": {
+			giveOpts:    SearchOptions{Attributes: []AttributeFilter{host}, From: &from},
+			wantIndexed: true,
+		},
+		"selective attribute with limit": {
+			giveOpts:    SearchOptions{Severities: []string{"info"}, Attributes: []AttributeFilter{trace}, Limit: 3},
+			wantIndexed: true,
+		},
+		"attribute not indexed": {
+			giveOpts: SearchOptions{Attributes: []AttributeFilter{notIndexed}},
+		},
+		"masked attribute": {
+			giveOpts: SearchOptions{Attributes: []AttributeFilter{trace}, Mask: []string{"trace_id"}},
+		},
+		"masked nested attribute": {
+			giveOpts: SearchOptions{Attributes: []AttributeFilter{trace}, Mask: []string{"trace_id.span"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, _, narrowed := indexed.plan(test.giveOpts)
+			require.Equal(t, test.wantIndexed, narrowed)
+
+			want, err := scanned.Get(test.giveOpts)
+			require.NoError(t, err)
+
+			got, err := indexed.Get(test.giveOpts)
+			require.NoError(t, err)
+
+			require.Equal(t, ids(want), ids(got))
+		})
+	}
+}
+
+func TestRepository_Entries(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tenants := NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{}, nil)
+	repo := tenants.Repository(DefaultTenant)
+
+	for i := range 100 {
+		_, err := repo.Create(LogEntry{Id: strconv.Itoa(i), Severity: "Info", Timestamp: start.Add(time.Duration(i) * time.Minute)})
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	entries, err := repo.Entries(ctx, SearchOptions{})
+	require.NoError(t, err)
+
+	var got []LogEntry
+
+	// Entries stop when the context is done, e.g. the client has gone away.
+	for entry := range entries {
+		if got = append(got, entry); len(got) == 10 {
+			cancel()
+		}
+	}
+
+	require.Equal(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}, ids(got))
+
+	entries, err = repo.Entries(context.Background(), SearchOptions{Descending: true, Limit: 3})
+	require.NoError(t, err)
+	require.Equal(t, []string{"99", "98", "97"}, ids(slices.Collect(entries)))
+}
+
+func TestRepository_ReplaceConcurrently(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tenants := NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{Duplicates: LastWriteWins}, nil)
+	repo := tenants.Repository(DefaultTenant)
+
+	var wg sync.WaitGroup
+
+	ready := make(chan struct{})
+
+	for i := range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			<-ready
+
+			_, err := repo.Create(LogEntry{Id: "1", Message: strconv.Itoa(i), Timestamp: start.Add(time.Duration(i) * time.Minute)})
+			require.NoError(t, err)
+		}()
+	}
+
+	close(ready)
+	wg.Wait()
+
+	// Only the entry stored last stays indexed.
+	i := tenants.indexesOf(DefaultTenant)
+	require.Len(t, i.ids, 1)
+	require.Equal(t, 1, i.event.Len())
+	require.Equal(t, 1, i.received.Len())
+	require.Equal(t, 1, i.text.Len())
+
+	require.Equal(t, 1, repo.DeleteBefore(start.Add(time.Hour)))
+	require.Empty(t, i.ids)
+	require.Zero(t, i.event.Len())
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"crypto/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+type (
+	// Ingestions keeps results of recent ingest requests of tenants, so that clients can look them up
+	// by the Location of PostLog responses.
+	Ingestions struct {
+		spaces *storage.Namespaced[ingestion]
+		ttl    atomic.Int64
+	}
+
+	// ingestion is a stored result of an ingest request.
+	ingestion struct {
+		result  api.IngestResult
+		created time.Time
+	}
+)
+
+// NewIngestions returns a new instance of Ingestions keeping results for the ttl.
+func NewIngestions(ttl time.Duration) *Ingestions {
+	i := &Ingestions{spaces: storage.NewNamespaced[ingestion]()}
+	i.Update(ttl)
+
+	return i
+}
+
+// Update changes how long results are kept, e.g. on configuration reload.
+func (i *Ingestions) Update(ttl time.Duration) {
+	i.ttl.Store(int64(ttl))
+}
+
+// Get returns the result of the ingest request of the tenant.
+func (i *Ingestions) Get(tenant, id string) (api.IngestResult, error) {
+	if id == "" {
+		return api.IngestResult{}, ErrBadRequestID
+	}
+
+	// Results of unknown tenants aren't looked up in storage created for them, so that requests
+	// of arbitrary tenants don't grow memory usage.
+	space, ok := i.spaces.Lookup(tenant)
+	if !ok {
+		return api.IngestResult{}, storage.ErrNotFound
+	}
+
+	stored, err := space.Get(storage.ID(id))
+	if err != nil {
+		return api.IngestResult{}, err
+	}
+
+	return stored.result, nil
+}
+
+// Expire deletes results older than the ttl and returns their number.
+func (i *Ingestions) Expire(now time.Time) int {
+	before := now.Add(-time.Duration(i.ttl.Load()))
+
+	var expired int
+
+	for _, tenant := range i.spaces.Names() {
+		space := i.spaces.Namespace(tenant)
+
+		// Find never fails for the in-memory storage.
+		results, _ := space.Find(func(value ingestion) bool {
+			return value.created.Before(before)
+		})
+
+		for _, r := range results {
+			space.Delete(r.ID())
+		}
+
+		expired += len(results)
+	}
+
+	return expired
+}
+
+// add stores the result of the ingest request of the tenant.
+func (i *Ingestions) add(tenant string, result api.IngestResult, now time.Time) {
+	// Insert never fails for results with IDs.
+	_ = i.spaces.Namespace(tenant).Insert(ingestion{result: result, created: now})
+}
+
+func (i ingestion) ID() storage.ID {
+	return storage.ID(i.result.Id)
+}
+
+// newIngestionID returns a random ID of an ingest request.
+func newIngestionID() string {
+	return rand.Text()
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestServer_PostLogResult(t *testing.T) {
+	tenants := NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{MaxEntries: 2}, nil)
+	ingestions := NewIngestions(time.Hour)
+	handler := api.Handler(NewServer(tenants, slog.New(slog.NewTextHandler(io.Discard, nil))).WithIngestions(ingestions))
+
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		return rec
+	}
+
+	entry := func(id string) string {
+		return `{"id":"` + id + `","message":"m","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}`
+	}
+
+	rec := serve(httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(`[`+entry("1")+`]`)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	body := `[` + entry("1") + `,` + entry("2") + `,` + entry("3") + `]`
+	rec = serve(httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(body)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	var result api.IngestResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+
+	require.NotEmpty(t, result.Id)
+	require.Equal(t, 2, result.Accepted)
+	require.Equal(t, []string{"1"}, result.Duplicates)
+	require.Len(t, result.Rejected, 1)
+	require.Equal(t, 2, result.Rejected[0].Index)
+	require.Contains(t, result.Rejected[0].Reason, ErrQuotaExceeded.Error())
+
+	location := rec.Header().Get("Location")
+	require.Equal(t, "/v1/ingestions/"+result.Id, location)
+
+	tests := map[string]struct {
+		giveTenant string
+		wantStatus int
+	}{
+		"same tenant": {
+			wantStatus: http.StatusOK,
+		},
+		"another tenant": {
+			giveTenant: "team-b",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, location, nil)
+			if test.giveTenant != "" {
+				r.Header.Set(TenantHeader, test.giveTenant)
+			}
+
+			rec := serve(r)
+			require.Equal(t, test.wantStatus, rec.Code)
+
+			if test.wantStatus == http.StatusOK {
+				var got api.IngestResult
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				require.Equal(t, result, got)
+			}
+		})
+	}
+
+	// Results of unknown tenants are looked up without creating them.
+	require.Equal(t, []string{DefaultTenant}, ingestions.spaces.Names())
+
+	require.Zero(t, ingestions.Expire(time.Now()))
+	require.Equal(t, 2, ingestions.Expire(time.Now().Add(2*time.Hour)))
+
+	require.Equal(t, http.StatusNotFound, serve(httptest.NewRequest(http.MethodGet, location, nil)).Code)
+}
@@ -2,12 +2,72 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
-func writeJSON(w http.ResponseWriter, v any) {
+// ndjsonContentType is the media type of newline-delimited JSON, one value per line.
+const ndjsonContentType = "application/x-ndjson"
+
+// flushInterval is how often lines of streamed responses are flushed to clients after the first one.
+const flushInterval = 100 * time.Millisecond
+
+// lineWriter writes values as lines of JSON to the response, flushing them periodically, so that
+// clients receive values as they are produced rather than when the response is complete.
+type lineWriter struct {
+	enc     *json.Encoder
+	rc      *http.ResponseController
+	flushed time.Time
+}
+
+func newLineWriter(w http.ResponseWriter) *lineWriter {
+	return &lineWriter{enc: json.NewEncoder(w), rc: http.NewResponseController(w)}
+}
+
+// Write writes the value as a line, it fails if the client has gone away.
+func (l *lineWriter) Write(v any) error {
+	if err := l.enc.Encode(v); err != nil {
+		return err
+	}
+
+	if time.Since(l.flushed) < flushInterval {
+		return nil
+	}
+
+	return l.Flush()
+}
+
+// Flush sends written lines to the client.
+func (l *lineWriter) Flush() error {
+	l.flushed = time.Now()
+
+	if err := l.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	return nil
+}
+
+// accepts reports whether the Accept header of the request lists the media type, wildcards aside.
+func accepts(r *http.Request, mediaType string) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			if t, _, err := mime.ParseMediaType(part); err == nil && t == mediaType {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
 	content, err := json.Marshal(v)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -16,6 +76,7 @@ func writeJSON(w http.ResponseWriter, v any) {
 
 	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
 
 	if _, err := w.Write(content); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -23,15 +84,21 @@ func writeJSON(w http.ResponseWriter, v any) {
 	}
 }
 
-func readJSON(r *http.Request, v any) error {
+// readBody reads the whole request body. Bodies cut by http.MaxBytesReader are reported as ErrBodyTooLarge.
+func readBody(r *http.Request) ([]byte, error) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		return err
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, fmt.Errorf("%w: at most %d bytes allowed", ErrBodyTooLarge, tooLarge.Limit)
+		}
+
+		return nil, err
 	}
 
 	if err := r.Body.Close(); err != nil {
-		return err
+		return nil, err
 	}
 
-	return json.Unmarshal(body, v)
+	return body, nil
 }
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestServer_ListLogsStream(t *testing.T) {
+	srv := httptest.NewServer(api.Handler(NewServer(
+		NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{}, nil),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)))
+	defer srv.Close()
+
+	entries := make([]string, 0, 250)
+	for i := range 250 {
+		entries = append(entries, fmt.Sprintf(
+			`{"id":"%d","message":"m","severity":"Info","timestamp":"2024-01-01T00:00:%02dZ","attributes":{}}`, i, i%60))
+	}
+
+	resp, err := http.Post(srv.URL+"/v1/logs", "application/json", strings.NewReader("["+strings.Join(entries, ",")+"]"))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	// get returns IDs of streamed entries and the cursor of the next page.
+	get := func(t *testing.T, query string) ([]string, string) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/logs"+query, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "application/json;q=0.5, application/x-ndjson")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, ndjsonContentType, resp.Header.Get("Content-Type"))
+		require.Empty(t, resp.Header.Get("Link"))
+
+		var ids []string
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var log api.Log
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &log))
+
+			ids = append(ids, log.Id)
+		}
+
+		require.NoError(t, scanner.Err())
+
+		// Trailers are read with the body.
+		return ids, resp.Trailer.Get(NextCursorHeader)
+	}
+
+	t.Run("all entries", func(t *testing.T) {
+		ids, next := get(t, "")
+
+		require.Len(t, ids, 250)
+		require.Empty(t, next)
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		var (
+			all   []string
+			pages int
+		)
+
+		for query := "?order=desc&limit=100"; query != ""; pages++ {
+			ids, next := get(t, query)
+			all = append(all, ids...)
+
+			query = ""
+			if next != "" {
+				query = "?order=desc&limit=100&cursor=" + next
+			}
+		}
+
+		require.Equal(t, 3, pages)
+
+		want, _ := get(t, "?order=desc")
+		require.Equal(t, want, all)
+	})
+
+	t.Run("bad options", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/logs?min_severity=loud", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", ndjsonContentType)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/auth"
+	"github.com/dyptan-io/log-management/v2/internal/platform/ratelimit"
+)
+
+// quotaWindow is the window of byte quotas of clients.
+const quotaWindow = 24 * time.Hour
+
+var (
+	// ErrRateLimited is an error when the client exceeds its rate limit or quota.
+	ErrRateLimited = errors.New("rate limit exceeded")
+	// ErrBatchTooLarge is an error when the request can never fit into the client limits.
+	ErrBatchTooLarge = errors.New("request exceeds the client limit")
+)
+
+type (
+	// RateLimits are ingestion limits of every client: an API key, or an IP address of unauthenticated
+	// requests. Zero values disable limits, zero bursts default to the rate.
+	RateLimits struct {
+		RequestsPerSecond float64 `yaml:"requests_per_second" json:"requests_per_second"`
+		RequestsBurst     int     `yaml:"requests_burst" json:"requests_burst"`
+		EntriesPerSecond  float64 `yaml:"entries_per_second" json:"entries_per_second"`
+		EntriesBurst      int     `yaml:"entries_burst" json:"entries_burst"`
+		// DailyBytes limits the size of request bodies per client per day, starting at midnight UTC.
+		DailyBytes int64 `yaml:"daily_bytes" json:"daily_bytes"`
+	}
+
+	// LimitError is an error when the client exceeds one of its limits.
+	LimitError struct {
+		// Limit is the name of the exceeded limit.
+		Limit string
+		// RetryAfter is the duration after which the request will be allowed.
+		RetryAfter time.Duration
+	}
+
+	// ClientLimits enforces RateLimits on clients.
+	ClientLimits struct {
+		mu       sync.RWMutex
+		limits   RateLimits
+		requests *ratelimit.Limiter
+		entries  *ratelimit.Limiter
+		bytes    *ratelimit.Quota
+	}
+
+	// ClientUsage is the usage of limits by a client. Available requests and entries are tokens
+	// of their buckets, remaining bytes are omitted if the daily quota is disabled.
+	ClientUsage struct {
+		Client              string  `json:"client"`
+		RequestsAvailable   float64 `json:"requests_available"`
+		EntriesAvailable    float64 `json:"entries_available"`
+		BytesToday          int64   `json:"bytes_today"`
+		BytesRemainingToday *int64  `json:"bytes_remaining_today,omitempty"`
+	}
+
+	// countingReader counts bytes read from the request body.
+	countingReader struct {
+		io.ReadCloser
+		n int64
+	}
+)
+
+// NewClientLimits returns a new instance of ClientLimits.
+func NewClientLimits(limits RateLimits) *ClientLimits {
+	l := &ClientLimits{
+		requests: ratelimit.New(0, 0),
+		entries:  ratelimit.New(0, 0),
+		bytes:    ratelimit.NewQuota(0, quotaWindow),
+	}
+	l.Update(limits)
+
+	return l
+}
+
+// Update replaces limits, e.g. on configuration reload. Usage of clients is kept.
+func (l *ClientLimits) Update(limits RateLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits = limits
+	l.requests.Update(limits.RequestsPerSecond, burstOf(limits.RequestsPerSecond, limits.RequestsBurst))
+	l.entries.Update(limits.EntriesPerSecond, burstOf(limits.EntriesPerSecond, limits.EntriesBurst))
+	l.bytes.Update(limits.DailyBytes)
+}
+
+// Prune forgets idle clients, so that the limits don't grow unbounded with short-living clients.
+func (l *ClientLimits) Prune() {
+	l.requests.Prune()
+	l.entries.Prune()
+}
+
+// Usage returns the usage of limits by clients that have sent requests recently.
+func (l *ClientLimits) Usage() []ClientUsage {
+	limits := l.current()
+	bytes := l.bytes.Usage()
+
+	clients := append(l.requests.Keys(), l.entries.Keys()...)
+	for client := range bytes {
+		clients = append(clients, client)
+	}
+
+	slices.Sort(clients)
+
+	usage := make([]ClientUsage, 0, len(clients))
+
+	for _, client := range slices.Compact(clients) {
+		u := ClientUsage{
+			Client:            client,
+			RequestsAvailable: l.requests.Tokens(client),
+			EntriesAvailable:  l.entries.Tokens(client),
+			BytesToday:        bytes[client],
+		}
+
+		if limits.DailyBytes > 0 {
+			remaining := max(0, limits.DailyBytes-u.BytesToday)
+			u.BytesRemainingToday = &remaining
+		}
+
+		usage = append(usage, u)
+	}
+
+	return usage
+}
+
+// ServeHTTP serves current limits and their usage by clients.
+func (l *ClientLimits) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, struct {
+		Limits  RateLimits    `json:"limits"`
+		Clients []ClientUsage `json:"clients"`
+	}{
+		Limits:  l.current(),
+		Clients: l.Usage(),
+	})
+}
+
+// allowRequest takes a token of the request rate limit of the client.
+func (l *ClientLimits) allowRequest(client string) error {
+	if l == nil || l.current().RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	ok, retry := l.requests.AllowN(client, 1)
+	if !ok {
+		return &LimitError{Limit: "requests per second", RetryAfter: retry}
+	}
+
+	return nil
+}
+
+// allowEntries takes n tokens of the entry rate limit of the client.
+func (l *ClientLimits) allowEntries(client string, n int) error {
+	if l == nil {
+		return nil
+	}
+
+	limits := l.current()
+	if limits.EntriesPerSecond <= 0 || n == 0 {
+		return nil
+	}
+
+	if burst := burstOf(limits.EntriesPerSecond, limits.EntriesBurst); n > burst {
+		return fmt.Errorf("%w: %d entries exceed the burst of %d", ErrBatchTooLarge, n, burst)
+	}
+
+	ok, retry := l.entries.AllowN(client, n)
+	if !ok {
+		return &LimitError{Limit: "entries per second", RetryAfter: retry}
+	}
+
+	return nil
+}
+
+// allowBytes adds n bytes to the daily quota of the client.
+func (l *ClientLimits) allowBytes(client string, n int64) error {
+	if l == nil {
+		return nil
+	}
+
+	limits := l.current()
+	if limits.DailyBytes <= 0 {
+		return nil
+	}
+
+	if n > limits.DailyBytes {
+		return fmt.Errorf("%w: %d bytes exceed the daily quota of %d", ErrBatchTooLarge, n, limits.DailyBytes)
+	}
+
+	ok, retry := l.bytes.AllowN(client, n)
+	if !ok {
+		return &LimitError{Limit: "daily bytes", RetryAfter: retry}
+	}
+
+	return nil
+}
+
+func (l *ClientLimits) current() RateLimits {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.limits
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %s, retry in %s", ErrRateLimited, e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+// Is makes LimitError match ErrRateLimited.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// retryAfter returns the value of the Retry-After header in whole seconds, rounded up.
+func (e *LimitError) retryAfter() string {
+	return strconv.Itoa(max(1, int(math.Ceil(e.RetryAfter.Seconds()))))
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+
+	return n, err
+}
+
+// clientOf returns the client the request is limited as: its API key, or its IP address
+// if the request is not authenticated.
+func clientOf(r *http.Request) string {
+	if key, ok := auth.FromContext(r.Context()); ok {
+		return "key:" + key.Name
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// burstOf returns the burst size, defaulting to the rate rounded up.
+func burstOf(rate float64, burst int) int {
+	if burst > 0 {
+		return burst
+	}
+
+	return max(1, int(math.Ceil(rate)))
+}
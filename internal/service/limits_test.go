@@ -0,0 +1,110 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/auth"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestServer_Limits(t *testing.T) {
+	const batch = `[{"id":"1","message":"a","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}},{"id":"2","message":"b","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}]`
+
+	tests := map[string]struct {
+		giveLimits  RateLimits
+		giveBodies  []string
+		wantStatus  []int
+		wantRetried bool
+	}{
+		"no limits": {
+			giveBodies: []string{batch, batch, batch},
+			wantStatus: []int{http.StatusAccepted, http.StatusAccepted, http.StatusAccepted},
+		},
+		"requests per second": {
+			giveLimits:  RateLimits{RequestsPerSecond: 0.1, RequestsBurst: 2},
+			giveBodies:  []string{batch, batch, batch},
+			wantStatus:  []int{http.StatusAccepted, http.StatusAccepted, http.StatusTooManyRequests},
+			wantRetried: true,
+		},
+		"entries per second": {
+			giveLimits:  RateLimits{EntriesPerSecond: 3},
+			giveBodies:  []string{batch, batch},
+			wantStatus:  []int{http.StatusAccepted, http.StatusTooManyRequests},
+			wantRetried: true,
+		},
+		"batch larger than entries burst": {
+			giveLimits: RateLimits{EntriesPerSecond: 1},
+			giveBodies: []string{batch},
+			wantStatus: []int{http.StatusRequestEntityTooLarge},
+		},
+		"daily bytes": {
+			giveLimits:  RateLimits{DailyBytes: int64(len(batch)) + 10},
+			giveBodies:  []string{batch, batch},
+			wantStatus:  []int{http.StatusAccepted, http.StatusTooManyRequests},
+			wantRetried: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tenants := NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{}, nil)
+			server := NewServer(tenants, slog.New(slog.NewTextHandler(io.Discard, nil))).
+				WithLimits(NewClientLimits(test.giveLimits))
+			handler := api.Handler(server)
+
+			var rec *httptest.ResponseRecorder
+
+			for i, body := range test.giveBodies {
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(body)))
+
+				require.Equal(t, test.wantStatus[i], rec.Code, rec.Body.String())
+			}
+
+			if test.wantRetried {
+				require.NotEmpty(t, rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestClientLimits_Usage(t *testing.T) {
+	limits := NewClientLimits(RateLimits{RequestsPerSecond: 0.001, RequestsBurst: 5, DailyBytes: 100})
+
+	key := httptest.NewRequest(http.MethodPost, "/v1/logs", nil)
+	key = key.WithContext(auth.NewContext(key.Context(), auth.Key{Name: "shipper"}))
+
+	anonymous := httptest.NewRequest(http.MethodPost, "/v1/logs", nil)
+	anonymous.RemoteAddr = "10.0.0.1:41234"
+
+	require.NoError(t, limits.allowRequest(clientOf(key)))
+	require.NoError(t, limits.allowBytes(clientOf(key), 30))
+	require.NoError(t, limits.allowRequest(clientOf(anonymous)))
+
+	rec := httptest.NewRecorder()
+	limits.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/limits", nil))
+
+	var got struct {
+		Limits  RateLimits    `json:"limits"`
+		Clients []ClientUsage `json:"clients"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+	require.EqualValues(t, 100, got.Limits.DailyBytes)
+	require.Len(t, got.Clients, 2)
+
+	require.Equal(t, "ip:10.0.0.1", got.Clients[0].Client)
+	require.Equal(t, "key:shipper", got.Clients[1].Client)
+	require.InDelta(t, 4, got.Clients[1].RequestsAvailable, 0.01)
+	require.EqualValues(t, 30, got.Clients[1].BytesToday)
+	require.EqualValues(t, 70, *got.Clients[1].BytesRemainingToday)
+}
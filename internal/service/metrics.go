@@ -0,0 +1,119 @@
+package service
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/metrics"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+// latencyBuckets are upper bounds of request latency histogram buckets in seconds.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type (
+	// Metrics are receiver metrics exposed in the Prometheus text format.
+	Metrics struct {
+		registry   *metrics.Registry
+		received   *metrics.Counter
+		duplicates metrics.CounterVec
+		latency    metrics.HistogramVec
+		errors     metrics.CounterVec
+	}
+
+	// instrumentedServer is an api.ServerInterface that measures latency and errors
+	// of every operation of the wrapped one.
+	instrumentedServer struct {
+		next    api.ServerInterface
+		metrics *Metrics
+	}
+
+	// statusWriter records the response status code.
+	statusWriter struct {
+		http.ResponseWriter
+		status int
+	}
+)
+
+// NewMetrics returns a new instance of Metrics reporting the size of the given storage.
+func NewMetrics(db *storage.Namespaced[LogEntry]) *Metrics {
+	r := metrics.NewRegistry()
+
+	r.NewGaugeFunc("receiver_entries_stored", "Log entries in the store.", func() int64 {
+		return int64(db.Len())
+	})
+	r.NewGaugeFunc("receiver_store_bytes", "Approximate memory used by stored log entries.", db.Bytes)
+
+	return &Metrics{
+		registry:   r,
+		received:   r.NewCounterVec("receiver_entries_received_total", "Log entries received for ingestion.").With(),
+		duplicates: r.NewCounterVec("receiver_entries_duplicate_total", "Received log entries with IDs that are already stored, by outcome.", "outcome"),
+		latency:    r.NewHistogramVec("receiver_request_duration_seconds", "Latency of API requests.", latencyBuckets, "operation"),
+		errors:     r.NewCounterVec("receiver_request_errors_total", "API requests that have failed, by status code.", "operation", "code"),
+	}
+}
+
+// ServeHTTP serves metrics to Prometheus scrapers.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.registry.ServeHTTP(w, r)
+}
+
+// Instrument returns api.ServerInterface that updates metrics of every operation of the given one.
+func Instrument(next api.ServerInterface, m *Metrics) api.ServerInterface {
+	return instrumentedServer{next: next, metrics: m}
+}
+
+func (s instrumentedServer) Health(w http.ResponseWriter, r *http.Request) {
+	s.observe("Health", w, func(w http.ResponseWriter) {
+		s.next.Health(w, r)
+	})
+}
+
+func (s instrumentedServer) ListLogs(w http.ResponseWriter, r *http.Request, params api.ListLogsParams) {
+	s.observe("ListLogs", w, func(w http.ResponseWriter) {
+		s.next.ListLogs(w, r, params)
+	})
+}
+
+func (s instrumentedServer) PostLog(w http.ResponseWriter, r *http.Request) {
+	s.observe("PostLog", w, func(w http.ResponseWriter) {
+		s.next.PostLog(w, r)
+	})
+}
+
+func (s instrumentedServer) GetLogsById(w http.ResponseWriter, r *http.Request, id string) {
+	s.observe("GetLogsById", w, func(w http.ResponseWriter) {
+		s.next.GetLogsById(w, r, id)
+	})
+}
+
+func (s instrumentedServer) GetIngestion(w http.ResponseWriter, r *http.Request, id string) {
+	s.observe("GetIngestion", w, func(w http.ResponseWriter) {
+		s.next.GetIngestion(w, r, id)
+	})
+}
+
+func (s instrumentedServer) observe(operation string, w http.ResponseWriter, serve func(w http.ResponseWriter)) {
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+
+	serve(sw)
+
+	s.metrics.latency.With(operation).Observe(time.Since(start).Seconds())
+
+	if sw.status >= http.StatusBadRequest {
+		s.metrics.errors.With(operation, strconv.Itoa(sw.status)).Inc()
+	}
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the original http.ResponseWriter for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestInstrument(t *testing.T) {
+	store := storage.NewNamespaced[LogEntry]()
+	metrics := NewMetrics(store)
+	tenants := NewTenants(store, TenantSettings{}, nil).WithMetrics(metrics)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	handler := api.Handler(Instrument(NewServer(tenants, logger), metrics))
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(`[{"id":"1","message":"a","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}},{"id":"2","message":"b","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}]`)),
+		httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(`[{"id":"1","message":"a","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}]`)),
+		httptest.NewRequest(http.MethodGet, "/v1/logs/3", nil),
+	}
+
+	for _, r := range requests {
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"receiver_entries_stored 2\n",
+		"receiver_entries_received_total 3\n",
+		`receiver_entries_duplicate_total{outcome="ignored"} 1` + "\n",
+		`receiver_request_duration_seconds_count{operation="PostLog"} 2` + "\n",
+		`receiver_request_errors_total{operation="GetLogsById",code="404"} 1` + "\n",
+	} {
+		require.Contains(t, body, want)
+	}
+
+	require.Positive(t, store.Bytes())
+}
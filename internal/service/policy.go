@@ -0,0 +1,168 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/auth"
+)
+
+// ErrUnknownRole is an error when the role of the API key has no policy.
+var ErrUnknownRole = errors.New("no policy for the role of the API key")
+
+type (
+	// Policy restricts Log entries readable by a role. Zero Policy allows reading everything.
+	Policy struct {
+		// Severities are severities of readable entries, compared case-insensitively. Any if empty.
+		Severities []string `yaml:"severities"`
+		// Match are attributes readable entries must have with the given values. Names may refer to
+		// nested attributes with dots, e.g. "user.id", and list attributes match if any item does.
+		Match map[string]string `yaml:"match"`
+		// Mask are names of attributes removed from readable entries, with the same notation as Match.
+		Mask []string `yaml:"mask"`
+	}
+
+	// Policies are policies of roles applied to requests of API keys having the role.
+	// Requests of keys without a role and unauthenticated requests aren't restricted.
+	Policies struct {
+		mu    sync.RWMutex
+		roles map[string]Policy
+	}
+)
+
+// NewPolicies returns a new instance of Policies.
+func NewPolicies(roles map[string]Policy) *Policies {
+	p := &Policies{}
+	p.Update(roles)
+
+	return p
+}
+
+// Update replaces policies of roles, e.g. on configuration reload.
+func (p *Policies) Update(roles map[string]Policy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.roles = roles
+}
+
+// policyOf returns the policy of the API key the request has been authenticated with.
+func (p *Policies) policyOf(r *http.Request) (Policy, error) {
+	key, ok := auth.FromContext(r.Context())
+	if p == nil || !ok || key.Role == "" {
+		return Policy{}, nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	policy, ok := p.roles[key.Role]
+	if !ok {
+		return Policy{}, fmt.Errorf("%w: %q", ErrUnknownRole, key.Role)
+	}
+
+	return policy, nil
+}
+
+// Allows reports whether the entry is readable under the policy.
+func (p Policy) Allows(entry LogEntry) bool {
+	if len(p.Severities) > 0 && !slices.ContainsFunc(p.Severities, func(s string) bool {
+		return strings.EqualFold(s, entry.Severity)
+	}) {
+		return false
+	}
+
+	for name, want := range p.Match {
+		v, ok := lookupAttribute(entry.Attributes, name)
+		if !ok || !matchValue(v, want) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply returns a copy of the entry with masked attributes removed. Stored attributes are not modified.
+func (p Policy) Apply(entry LogEntry) LogEntry {
+	return maskAttributes(entry, p.Mask)
+}
+
+// maskAttributes returns a copy of the entry without the named attributes, it's the entry itself
+// if there are no names.
+func maskAttributes(entry LogEntry, names []string) LogEntry {
+	for _, name := range names {
+		entry.Attributes, _ = removeAttribute(entry.Attributes, name)
+	}
+
+	return entry
+}
+
+// lookupAttribute returns the attribute by its name, or by its path of nested attribute names
+// separated with dots if there's no attribute with the whole name.
+func lookupAttribute(attrs map[string]any, name string) (any, bool) {
+	if v, ok := attrs[name]; ok {
+		return v, true
+	}
+
+	head, tail, ok := strings.Cut(name, ".")
+	if !ok {
+		return nil, false
+	}
+
+	nested, ok := attrs[head].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	return lookupAttribute(nested, tail)
+}
+
+// removeAttribute returns attributes without the one named as in lookupAttribute and whether
+// it has been found. Maps on the path to the attribute are copied, the given ones are never modified.
+func removeAttribute(attrs map[string]any, name string) (map[string]any, bool) {
+	if _, ok := attrs[name]; ok {
+		attrs = maps.Clone(attrs)
+		delete(attrs, name)
+
+		return attrs, true
+	}
+
+	head, tail, ok := strings.Cut(name, ".")
+	if !ok {
+		return attrs, false
+	}
+
+	nested, ok := attrs[head].(map[string]any)
+	if !ok {
+		return attrs, false
+	}
+
+	nested, ok = removeAttribute(nested, tail)
+	if !ok {
+		return attrs, false
+	}
+
+	attrs = maps.Clone(attrs)
+	attrs[head] = nested
+
+	return attrs, true
+}
+
+func matchValue(v any, want string) bool {
+	if items, ok := v.([]any); ok {
+		for _, item := range items {
+			if fmt.Sprint(item) == want {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return fmt.Sprint(v) == want
+}
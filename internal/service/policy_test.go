@@ -0,0 +1,206 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/auth"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestServer_Policies(t *testing.T) {
+	store := storage.NewNamespaced[LogEntry]()
+	tenants := NewTenants(store, TenantSettings{}, nil)
+	policies := NewPolicies(map[string]Policy{
+		"payments-oncall": {
+			Severities: []string{"error"},
+			Match:      map[string]string{"service": "payments"},
+			Mask:       []string{"card", "user.email"},
+		},
+	})
+	handler := api.Handler(NewServer(tenants, slog.New(slog.NewTextHandler(io.Discard, nil))).WithPolicies(policies))
+
+	repo := tenants.Repository(DefaultTenant)
+	now := time.Now()
+
+	for _, entry := range []LogEntry{
+		{Id: "1", Severity: "Error", Timestamp: now, Attributes: map[string]any{
+			"service": "payments",
+			"card":    "4242",
+			"user":    map[string]any{"id": "u1", "email": "u1@example.com"},
+		}},
+		{Id: "2", Severity: "Info", Timestamp: now, Attributes: map[string]any{"service": "payments"}},
+		{Id: "3", Severity: "Error", Timestamp: now, Attributes: map[string]any{"service": "search"}},
+	} {
+		_, err := repo.Create(entry)
+		require.NoError(t, err)
+	}
+
+	get := func(path string, role string) (int, string) {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r = r.WithContext(auth.NewContext(r.Context(), auth.Key{Name: "k", Role: role}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		return rec.Code, rec.Body.String()
+	}
+
+	masked := api.Log{
+		Id:         "1",
+		Severity:   "Error",
+		Timestamp:  now,
+		Attributes: map[string]any{"service": "payments", "user": map[string]any{"id": "u1"}},
+	}
+
+	tests := map[string]struct {
+		givePath   string
+		giveRole   string
+		wantStatus int
+		wantLogs   []api.Log
+		wantLog    *api.Log
+	}{
+		"list without role": {
+			givePath:   "/v1/logs",
+			wantStatus: http.StatusOK,
+		},
+		"list with role": {
+			givePath:   "/v1/logs",
+			giveRole:   "payments-oncall",
+			wantStatus: http.StatusOK,
+			wantLogs:   []api.Log{masked},
+		},
+		"get allowed entry": {
+			givePath:   "/v1/logs/1",
+			giveRole:   "payments-oncall",
+			wantStatus: http.StatusOK,
+			wantLog:    &masked,
+		},
+		"get filtered out entry": {
+			givePath:   "/v1/logs/3",
+			giveRole:   "payments-oncall",
+			wantStatus: http.StatusNotFound,
+		},
+		"filter by masked attribute": {
+			givePath:   "/v1/logs?attr=user.email=u1@example.com",
+			giveRole:   "payments-oncall",
+			wantStatus: http.StatusOK,
+			wantLogs:   []api.Log{},
+		},
+		"filter by missing masked attribute": {
+			givePath:   "/v1/logs?attr=!card",
+			giveRole:   "payments-oncall",
+			wantStatus: http.StatusOK,
+			wantLogs:   []api.Log{masked},
+		},
+		"query of masked attribute": {
+			givePath:   `/v1/logs?q=attributes.user.email~"^u1"`,
+			giveRole:   "payments-oncall",
+			wantStatus: http.StatusOK,
+			wantLogs:   []api.Log{},
+		},
+		"query of masked attribute existence": {
+			givePath:   "/v1/logs?q=card:*",
+			giveRole:   "payments-oncall",
+			wantStatus: http.StatusOK,
+			wantLogs:   []api.Log{},
+		},
+		"unknown role": {
+			givePath:   "/v1/logs",
+			giveRole:   "intern",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			status, body := get(test.givePath, test.giveRole)
+			require.Equal(t, test.wantStatus, status)
+
+			switch {
+			case test.wantLogs != nil:
+				var logs []api.Log
+				require.NoError(t, json.Unmarshal([]byte(body), &logs))
+				requireLogs(t, test.wantLogs, logs)
+			case test.wantLog != nil:
+				var log api.Log
+				require.NoError(t, json.Unmarshal([]byte(body), &log))
+				requireLogs(t, []api.Log{*test.wantLog}, []api.Log{log})
+			case test.wantStatus == http.StatusOK:
+				var logs []api.Log
+				require.NoError(t, json.Unmarshal([]byte(body), &logs))
+				require.Len(t, logs, 3)
+			}
+		})
+	}
+
+	// Masking doesn't modify stored entries.
+	entry, err := repo.GetByID("1")
+	require.NoError(t, err)
+	require.Equal(t, "4242", entry.Attributes["card"])
+	require.Equal(t, "u1@example.com", entry.Attributes["user"].(map[string]any)["email"])
+}
+
+func requireLogs(t *testing.T, want, got []api.Log) {
+	t.Helper()
+
+	require.Len(t, got, len(want))
+
+	for i := range want {
+		require.Equal(t, want[i].Id, got[i].Id)
+		require.Equal(t, want[i].Severity, got[i].Severity)
+		require.Equal(t, want[i].Attributes, got[i].Attributes)
+	}
+}
+
+func TestPolicy_Allows(t *testing.T) {
+	entry := LogEntry{
+		Severity:   "Warning",
+		Attributes: map[string]any{"tags": []any{"a", "b"}, "http.status": 500, "user": map[string]any{"id": "u1"}},
+	}
+
+	tests := map[string]struct {
+		givePolicy Policy
+		want       bool
+	}{
+		"zero policy": {
+			want: true,
+		},
+		"severity": {
+			givePolicy: Policy{Severities: []string{"error", "WARNING"}},
+			want:       true,
+		},
+		"other severity": {
+			givePolicy: Policy{Severities: []string{"Error"}},
+		},
+		"list item": {
+			givePolicy: Policy{Match: map[string]string{"tags": "b"}},
+			want:       true,
+		},
+		"dotted name": {
+			givePolicy: Policy{Match: map[string]string{"http.status": "500"}},
+			want:       true,
+		},
+		"nested attribute": {
+			givePolicy: Policy{Match: map[string]string{"user.id": "u1"}},
+			want:       true,
+		},
+		"missing attribute": {
+			givePolicy: Policy{Match: map[string]string{"service": "payments"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.want, test.givePolicy.Allows(entry))
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/query"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+// attributesPrefix is the prefix of attribute fields of queries. Fields that aren't fields of
+// Log entries are attributes as well, e.g. "service" is "attributes.service".
+const attributesPrefix = "attributes."
+
+// CompileQuery returns the matcher of Log entries selected by the query, e.g.
+//
+//	severity>=warn AND (service:payments OR message~"timeout") AND NOT attributes.env:dev
+//
+// Fields are id, message, severity, timestamp, received_at, sequence, and attributes. The ":" operator
+// matches messages containing the value, other fields equal to it, and attributes that exist if the value
+// is an unquoted "*". The "~" operator matches regular expressions. Severities are compared by their levels,
+// times as RFC 3339 date-times, and attributes are compared numerically as in AttributeFilter.
+func CompileQuery(s string) (storage.Matcher[LogEntry], error) {
+	expr, err := query.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: q: %v", ErrBadSearch, err)
+	}
+
+	match, err := compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: q: %v", ErrBadSearch, err)
+	}
+
+	return match, nil
+}
+
+func compile(expr query.Expr) (storage.Matcher[LogEntry], error) {
+	switch e := expr.(type) {
+	case query.And:
+		left, right, err := compileBoth(e.Left, e.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(v LogEntry) bool { return left(v) && right(v) }, nil
+	case query.Or:
+		left, right, err := compileBoth(e.Left, e.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(v LogEntry) bool { return left(v) || right(v) }, nil
+	case query.Not:
+		match, err := compile(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(v LogEntry) bool { return !match(v) }, nil
+	case query.Comparison:
+		return compileComparison(e)
+	}
+
+	return nil, query.Errorf(expr.Pos(), "unsupported expression")
+}
+
+func compileBoth(left, right query.Expr) (storage.Matcher[LogEntry], storage.Matcher[LogEntry], error) {
+	l, err := compile(left)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := compile(right)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return l, r, nil
+}
+
+func compileComparison(c query.Comparison) (storage.Matcher[LogEntry], error) {
+	switch c.Field {
+	case "id":
+		return compileString(c, false, func(v LogEntry) string { return v.Id })
+	case "message":
+		return compileString(c, true, func(v LogEntry) string { return v.Message })
+	case "severity":
+		return compileSeverity(c)
+	case "timestamp":
+		return compileTime(c, EventTime.of)
+	case "received_at":
+		return compileTime(c, ReceivedTime.of)
+	case "sequence":
+		n, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil {
+			return nil, query.Errorf(c.ValuePos, "sequence must be an integer, got %q", c.Value)
+		}
+
+		return compileOrdered(c, func(v LogEntry) int { return cmp.Compare(v.Sequence, n) })
+	}
+
+	return compileAttribute(c, strings.TrimPrefix(c.Field, attributesPrefix))
+}
+
+// compileString compiles comparisons of string fields, which match values containing the one of
+// the query with the ":" operator if contains is set.
+func compileString(c query.Comparison, contains bool, field func(LogEntry) string) (storage.Matcher[LogEntry], error) {
+	switch c.Op {
+	case query.Match:
+		if contains {
+			return func(v LogEntry) bool { return strings.Contains(field(v), c.Value) }, nil
+		}
+
+		return func(v LogEntry) bool { return field(v) == c.Value }, nil
+	case query.Equal:
+		return func(v LogEntry) bool { return field(v) == c.Value }, nil
+	case query.NotEqual:
+		return func(v LogEntry) bool { return field(v) != c.Value }, nil
+	case query.Regexp:
+		pattern, err := compileRegexp(c)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(v LogEntry) bool { return pattern.MatchString(field(v)) }, nil
+	}
+
+	return nil, unsupported(c)
+}
+
+func compileSeverity(c query.Comparison) (storage.Matcher[LogEntry], error) {
+	switch c.Op {
+	case query.Match, query.Equal:
+		return func(v LogEntry) bool { return strings.EqualFold(v.Severity, c.Value) }, nil
+	case query.NotEqual:
+		return func(v LogEntry) bool { return !strings.EqualFold(v.Severity, c.Value) }, nil
+	case query.Regexp:
+		return compileString(c, false, func(v LogEntry) string { return v.Severity })
+	}
+
+	want, ok := severityLevel(c.Value)
+	if !ok {
+		return nil, query.Errorf(c.ValuePos, "unknown severity %q", c.Value)
+	}
+
+	return func(v LogEntry) bool {
+		// Entries with unknown severities are neither less nor more severe than any level.
+		level, ok := severityLevel(v.Severity)
+
+		return ok && compareOp(c.Op, cmp.Compare(level, want))
+	}, nil
+}
+
+func compileTime(c query.Comparison, field func(LogEntry) time.Time) (storage.Matcher[LogEntry], error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return nil, query.Errorf(c.ValuePos, "%s must be an RFC 3339 date-time, got %q", c.Field, c.Value)
+	}
+
+	return compileOrdered(c, func(v LogEntry) int { return field(v).Compare(t) })
+}
+
+// compileOrdered compiles comparisons of ordered fields by the result of comparing them to the value.
+func compileOrdered(c query.Comparison, compare func(LogEntry) int) (storage.Matcher[LogEntry], error) {
+	if c.Op == query.Regexp {
+		return nil, unsupported(c)
+	}
+
+	return func(v LogEntry) bool { return compareOp(c.Op, compare(v)) }, nil
+}
+
+func compileAttribute(c query.Comparison, name string) (storage.Matcher[LogEntry], error) {
+	op := AttributeOp(c.Op)
+
+	switch c.Op {
+	case query.Match:
+		op = AttributeEqual
+
+		if c.Value == "*" && !c.Quoted {
+			op = AttributeExists
+		}
+	case query.Regexp:
+		pattern, err := compileRegexp(c)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(v LogEntry) bool {
+			value, ok := lookupAttribute(v.Attributes, name)
+			if !ok {
+				return false
+			}
+
+			items, isList := value.([]any)
+			if !isList {
+				items = []any{value}
+			}
+
+			for _, item := range items {
+				if pattern.MatchString(fmt.Sprint(item)) {
+					return true
+				}
+			}
+
+			return false
+		}, nil
+	}
+
+	f, err := NewAttributeFilter(name, op, c.Value)
+	if err != nil {
+		return nil, query.Errorf(c.ValuePos, "%s must be compared to a number, got %q", c.Field, c.Value)
+	}
+
+	return func(v LogEntry) bool { return f.Match(v.Attributes) }, nil
+}
+
+func compileRegexp(c query.Comparison) (*regexp.Regexp, error) {
+	pattern, err := regexp.Compile(c.Value)
+	if err != nil {
+		return nil, query.Errorf(c.ValuePos, "malformed regular expression: %v", err)
+	}
+
+	return pattern, nil
+}
+
+// compareOp reports whether the result of comparing a field to a value satisfies the operator.
+func compareOp(op query.Op, c int) bool {
+	switch op {
+	case query.Match, query.Equal:
+		return c == 0
+	case query.NotEqual:
+		return c != 0
+	case query.Less:
+		return c < 0
+	case query.LessOrEqual:
+		return c <= 0
+	case query.Greater:
+		return c > 0
+	case query.GreaterOrEqual:
+		return c >= 0
+	}
+
+	return false
+}
+
+func unsupported(c query.Comparison) error {
+	return query.Errorf(c.ValuePos-len(c.Op), "operator %q isn't supported by %s", c.Op, c.Field)
+}
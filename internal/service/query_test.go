@@ -0,0 +1,186 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestCompileQuery(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	entries := []LogEntry{
+		{Id: "1", Message: "payment timeout", Severity: "Error", Timestamp: day, Sequence: 1,
+			Attributes: map[string]any{"service": "payments", "env": "prod", "http": map[string]any{"status": 504.0}}},
+		{Id: "2", Message: "payment accepted", Severity: "Info", Timestamp: day.Add(time.Hour), Sequence: 2,
+			Attributes: map[string]any{"service": "payments", "env": "dev"}},
+		{Id: "3", Message: "upstream timeout", Severity: "Warning", Timestamp: day.Add(2 * time.Hour), Sequence: 3,
+			Attributes: map[string]any{"service": "gateway", "env": "dev", "tags": []any{"edge", "eu"}}},
+		{Id: "4", Message: "disk (almost) full", Severity: "custom", Timestamp: day.Add(3 * time.Hour), Sequence: 4},
+	}
+
+	tests := map[string]struct {
+		give    string
+		wantIDs []string
+	}{
+		"example": {
+			give:    `severity>=warn AND (service:payments OR message~"timeout") AND NOT attributes.env:dev`,
+			wantIDs: []string{"1"},
+		},
+		"OR": {
+			give:    "id:2 OR id=4",
+			wantIDs: []string{"2", "4"},
+		},
+		"message contains": {
+			give:    `message:"(almost)"`,
+			wantIDs: []string{"4"},
+		},
+		"message equals": {
+			give:    `message="payment timeout"`,
+			wantIDs: []string{"1"},
+		},
+		"severity": {
+			give:    "severity:INFO OR severity<info",
+			wantIDs: []string{"2"},
+		},
+		"severity not equal": {
+			give:    "severity!=error",
+			wantIDs: []string{"2", "3", "4"},
+		},
+		"timestamp": {
+			give:    "timestamp>=2024-01-02T01:00:00Z AND timestamp<2024-01-02T03:00:00Z",
+			wantIDs: []string{"2", "3"},
+		},
+		"sequence": {
+			give:    "sequence>2",
+			wantIDs: []string{"3", "4"},
+		},
+		"attribute exists": {
+			give:    "http.status:*",
+			wantIDs: []string{"1"},
+		},
+		"attribute missing": {
+			give:    "NOT service:*",
+			wantIDs: []string{"4"},
+		},
+		"numeric attribute": {
+			give:    "attributes.http.status>=500",
+			wantIDs: []string{"1"},
+		},
+		"list attribute": {
+			give:    "tags:eu AND tags~^ed",
+			wantIDs: []string{"3"},
+		},
+		"keywords in lower case": {
+			give:    "not env:prod and not service:gateway",
+			wantIDs: []string{"2", "4"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			match, err := CompileQuery(test.give)
+			require.NoError(t, err)
+
+			var ids []string
+
+			for _, entry := range entries {
+				if match(entry) {
+					ids = append(ids, entry.Id)
+				}
+			}
+
+			require.Equal(t, test.wantIDs, ids)
+		})
+	}
+}
+
+func TestCompileQuery_Errors(t *testing.T) {
+	tests := map[string]struct {
+		give    string
+		wantErr string
+	}{
+		"syntax error": {
+			give:    "severity>=warn AND",
+			wantErr: "q: column 19: unexpected end of the query, expected a field",
+		},
+		"unknown severity": {
+			give:    "severity>=loud",
+			wantErr: `q: column 11: unknown severity "loud"`,
+		},
+		"malformed time": {
+			give:    "timestamp>yesterday",
+			wantErr: `q: column 11: timestamp must be an RFC 3339 date-time, got "yesterday"`,
+		},
+		"malformed regular expression": {
+			give:    "id:1 OR message~(",
+			wantErr: "q: column 17: unexpected \"(\", expected a value after \"message~\"",
+		},
+		"malformed quoted regular expression": {
+			give:    `message~"("`,
+			wantErr: "q: column 9: malformed regular expression: error parsing regexp: missing closing ): `(`",
+		},
+		"unsupported operator": {
+			give:    "message>a",
+			wantErr: `q: column 8: operator ">" isn't supported by message`,
+		},
+		"non-numeric attribute comparison": {
+			give:    "http.status>=high",
+			wantErr: `q: column 14: http.status must be compared to a number, got "high"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := CompileQuery(test.give)
+
+			require.ErrorIs(t, err, ErrBadSearch)
+			require.EqualError(t, err, ErrBadSearch.Error()+": "+test.wantErr)
+		})
+	}
+}
+
+func TestServer_ListLogsQuery(t *testing.T) {
+	handler := api.Handler(NewServer(
+		NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{}, nil),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	))
+
+	rec := httptest.NewRecorder()
+	body := `[{"id":"1","message":"timeout","severity":"Error","timestamp":"2024-01-02T00:00:00Z","attributes":{"service":"payments"}},` +
+		`{"id":"2","message":"ok","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{"service":"payments"}}]`
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(body)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/logs?"+url.Values{"q": {"service:payments AND severity>=warn"}}.Encode(), nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var logs []api.Log
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &logs))
+	require.Len(t, logs, 1)
+	require.Equal(t, "1", logs[0].Id)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/logs?"+url.Values{"q": {"service payments"}}.Encode(), nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var resp api.ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, []string{`invalid search options: q: column 8: unexpected " ", expected an operator after "service"`}, resp.Errors)
+}
@@ -1,10 +1,19 @@
 package service
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
+	"unsafe"
 
+	"github.com/dyptan-io/log-management/v2/internal/platform/index"
+	"github.com/dyptan-io/log-management/v2/internal/platform/metrics"
 	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
 )
 
@@ -15,6 +24,16 @@ type (
 	// Repository is a struct that manipulates the Log entries.
 	Repository struct {
 		db *storage.InMemory[LogEntry]
+
+		// Counters are not updated unless metrics are enabled with WithMetrics.
+		received  *metrics.Counter
+		ignored   *metrics.Counter
+		replaced  *metrics.Counter
+		conflicts *metrics.Counter
+
+		settings TenantSettings
+		// indexes are nil unless the repository is one of Tenants, then entries are found by scanning.
+		indexes *indexes
 	}
 
 	// LogEntry is a struct that represents log entry data model on persistence level.
@@ -25,19 +44,78 @@ type (
 		Severity   string
 		Timestamp  time.Time
 		Attributes map[string]any
+		// ReceivedAt and Sequence are assigned by the repository when the entry is stored.
+		ReceivedAt time.Time
+		Sequence   int64
 	}
 
+	// TimeField is a time of Log entries searches filter and sort them by.
+	TimeField string
+
 	SearchOptions struct {
 		From *time.Time
 		To   *time.Time
+		// Time is the time From and To refer to and entries are sorted by, EventTime by default.
+		Time TimeField
+		// Descending sorts entries from the latest.
+		Descending bool
+		// After skips entries up to the cursor, which must be of the same Time and order.
+		After *Cursor
+		// Limit is the maximal number of returned entries, unless it's zero.
+		Limit int
+		// Match filters entries, if it's set.
+		Match func(LogEntry) bool
+
+		// Severities are severities of entries, compared case-insensitively.
+		Severities []string
+		// MinSeverity is the minimal severity level of entries, e.g. "warn" for warnings and more severe ones.
+		MinSeverity string
+		// Message is a substring of entry messages.
+		Message string
+		// MessagePattern is a regular expression matching entry messages.
+		MessagePattern *regexp.Regexp
+		// IDPrefix is a prefix of entry IDs.
+		IDPrefix string
+		// Attributes are filters that entry attributes must all match.
+		Attributes []AttributeFilter
+		// Mask are names of attributes hidden from the caller, as in Policy.Mask. Filters and the query
+		// don't see them, so that masked values can't be inferred from entries they select.
+		Mask []string
+		// Query matches entries, if it's set, e.g. compiled with CompileQuery.
+		Query storage.Matcher[LogEntry]
+		// Text is a full-text query of messages, parsed with index.ParseTextQuery.
+		Text string
+	}
+
+	// Page is a page of Log entries found by search criteria.
+	Page struct {
+		Entries []LogEntry
+		// Next is the cursor of the next page, it's nil on the last page.
+		Next *Cursor
 	}
 )
 
+// Times of Log entries.
+const (
+	// EventTime is the timestamp of the entry given by its producer.
+	EventTime TimeField = "event"
+	// ReceivedTime is the time the entry has been stored at.
+	ReceivedTime TimeField = "received"
+)
+
+// ErrBadSearch is an error when search options are malformed.
+var ErrBadSearch = errors.New("invalid search options")
+
 // ID returns a storage record ID for the LogEntry to comply with model constants.
 func (l LogEntry) ID() storage.ID {
 	return storage.ID(l.Id)
 }
 
+// Size returns the approximate memory size of the LogEntry in bytes.
+func (l LogEntry) Size() int {
+	return int(unsafe.Sizeof(l)) + len(l.Id) + len(l.Message) + len(l.Severity) + sizeOfValue(l.Attributes)
+}
+
 // NewRepository creates a new instance of Repository type.
 func NewRepository(db *storage.InMemory[LogEntry]) Repository {
 	return Repository{
@@ -45,6 +123,31 @@ func NewRepository(db *storage.InMemory[LogEntry]) Repository {
 	}
 }
 
+// WithMetrics returns a copy of Repository that updates the given metrics.
+func (r Repository) WithMetrics(m *Metrics) Repository {
+	r.received = m.received
+	r.ignored = m.duplicates.With("ignored")
+	r.replaced = m.duplicates.With("replaced")
+	r.conflicts = m.duplicates.With("conflict")
+
+	return r
+}
+
+// withSettings returns a copy of Repository that rejects new entries beyond the quota and
+// handles duplicates according to the policy of the settings.
+func (r Repository) withSettings(s TenantSettings) Repository {
+	r.settings = s
+
+	return r
+}
+
+// withIndexes returns a copy of Repository that maintains and searches the indexes.
+func (r Repository) withIndexes(i *indexes) Repository {
+	r.indexes = i
+
+	return r
+}
+
 // GetByID returns a Log entry for the given ID.
 func (r Repository) GetByID(id string) (LogEntry, error) {
 	if id == "" {
@@ -59,32 +162,342 @@ func (r Repository) GetByID(id string) (LogEntry, error) {
 	return entry, nil
 }
 
-// Get returns Log entries by search criteria.
+// Get returns Log entries by search criteria sorted by the time, and in the ingest order if the times are equal.
 func (r Repository) Get(opts SearchOptions) ([]LogEntry, error) {
-	res, err := r.db.Find(func(value LogEntry) bool {
-		if opts.From != nil && opts.From.After(value.Timestamp) {
+	entries, err := r.Entries(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Collect(entries), nil
+}
+
+// Entries returns an iterator of Log entries by search criteria in the order of Get, which stops when the context
+// is done. Entries are produced as they are read from the time index, unless they are searched by text or indexed
+// filters, then they are found and sorted before the first one is produced.
+func (r Repository) Entries(ctx context.Context, opts SearchOptions) (iter.Seq[LogEntry], error) {
+	if opts.Time == "" {
+		opts.Time = EventTime
+	}
+
+	if opts.Time != EventTime && opts.Time != ReceivedTime {
+		return nil, fmt.Errorf("%w: unknown time %q", ErrBadSearch, opts.Time)
+	}
+
+	timeOf := opts.Time.of
+
+	if opts.After != nil && (opts.After.Time != opts.Time || opts.After.Descending != opts.Descending) {
+		return nil, fmt.Errorf("%w: cursor of another time or order", ErrBadSearch)
+	}
+
+	filter, err := opts.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	// compare orders entries by the time and sequence in the order of the search.
+	compare := func(t1 time.Time, seq1 int64, t2 time.Time, seq2 int64) int {
+		c := cmp.Or(t1.Compare(t2), cmp.Compare(seq1, seq2))
+		if opts.Descending {
+			return -c
+		}
+
+		return c
+	}
+
+	match := func(value LogEntry) bool {
+		if opts.From != nil && opts.From.After(timeOf(value)) {
+			return false
+		}
+
+		if opts.To != nil && opts.To.Before(timeOf(value)) {
 			return false
 		}
 
-		if opts.To != nil && opts.To.Before(value.Timestamp) {
+		if opts.After != nil && compare(timeOf(value), value.Sequence, opts.After.At, opts.After.Sequence) <= 0 {
 			return false
 		}
 
-		return true
+		if opts.Match != nil && !opts.Match(value) {
+			return false
+		}
+
+		view := maskAttributes(value, opts.Mask)
+
+		return filter(view) && (opts.Query == nil || opts.Query(view))
+	}
+
+	text := index.ParseTextQuery(opts.Text)
+
+	var res []LogEntry
+
+	if text.IsZero() {
+		name, values, narrowed := r.plan(opts)
+
+		switch {
+		case narrowed:
+			res, err = r.db.FindBy(name, values, match)
+		case r.indexes != nil:
+			// Entries are read in order from the time index, up to the limit.
+			return take(ctx, r.indexes.scan(ctx, r.db, opts, match), opts.Limit), nil
+		default:
+			res, err = r.db.Find(match)
+		}
+	} else {
+		res, err = r.find(text, match)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting all log entries: %w", err)
+	}
+
+	slices.SortFunc(res, func(a, b LogEntry) int {
+		return compare(timeOf(a), a.Sequence, timeOf(b), b.Sequence)
 	})
 
+	return take(ctx, slices.Values(res), opts.Limit), nil
+}
+
+// take returns an iterator of up to n entries of the sequence, or of all of them if n is zero,
+// which stops when the context is done.
+func take(ctx context.Context, entries iter.Seq[LogEntry], n int) iter.Seq[LogEntry] {
+	return func(yield func(LogEntry) bool) {
+		var i int
+
+		for entry := range entries {
+			if ctx.Err() != nil || !yield(entry) {
+				return
+			}
+
+			if i++; i == n {
+				return
+			}
+		}
+	}
+}
+
+// plan returns the storage index and values of the filters of the options that narrow down entries
+// the most, if reading entries having the values costs less than reading them from the time index.
+func (r Repository) plan(opts SearchOptions) (string, []string, bool) {
+	var (
+		name   string
+		values []string
+		count  = -1
+	)
+
+	consider := func(index string, vs []string) {
+		if n, ok := r.db.Count(index, vs...); ok && (count < 0 || n < count) {
+			name, values, count = index, vs, n
+		}
+	}
+
+	if len(opts.Severities) > 0 {
+		severities := make([]string, 0, len(opts.Severities))
+		for _, s := range opts.Severities {
+			severities = append(severities, strings.ToLower(s))
+		}
+
+		consider(severityIndex, severities)
+	}
+
+	for _, f := range opts.Attributes {
+		// Indexes of masked attributes would select entries by values the caller can't see.
+		if f.Op == AttributeEqual && !slices.ContainsFunc(opts.Mask, func(name string) bool { return overlaps(name, f.Name) }) {
+			consider(attributeIndex(f.Name), []string{f.Value})
+		}
+	}
+
+	if count < 0 {
+		return "", nil, false
+	}
+
+	if r.indexes == nil || opts.Limit == 0 {
+		return name, values, count < r.db.Len()
+	}
+
+	// The time index reads about limit/selectivity entries to fill the limit, where the selectivity
+	// of the filter is count/len, so it costs less if count*count > limit*len.
+	return name, values, int64(count)*int64(count) <= int64(opts.Limit)*int64(r.db.Len())
+}
+
+// overlaps reports whether attribute names refer to the same attribute or one is nested in the other.
+func overlaps(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+// find returns entries matching the text query and the matcher, by the text index if there is one.
+func (r Repository) find(text index.TextQuery, match storage.Matcher[LogEntry]) ([]LogEntry, error) {
+	if r.indexes != nil {
+		return r.indexes.searchText(r.db, text, match), nil
+	}
+
+	return r.db.Find(func(value LogEntry) bool {
+		return text.Match(value.Message) && match(value)
+	})
+}
+
+// GetPage returns a page of Log entries by search criteria like Get, with the cursor of the next page if
+// there are more entries than the limit. Entries inserted concurrently don't shift pages, since cursors
+// point to the last entry of the previous page rather than to offsets.
+func (r Repository) GetPage(opts SearchOptions) (Page, error) {
+	limit := opts.Limit
+	if limit > 0 {
+		// One more entry tells whether there is a next page.
+		opts.Limit++
+	}
+
+	entries, err := r.Get(opts)
 	if err != nil {
-		return []LogEntry{}, fmt.Errorf("getting all log entries: %w", err)
+		return Page{}, err
 	}
 
-	return res, nil
+	if limit == 0 || len(entries) <= limit {
+		return Page{Entries: entries}, nil
+	}
+
+	entries = entries[:limit]
+
+	return Page{Entries: entries, Next: cursorAt(opts, entries[limit-1])}, nil
 }
 
-// Create creates a new Log entity in the storage.
-func (r Repository) Create(entry LogEntry) (LogEntry, error) {
-	if err := r.db.Insert(entry); err != nil {
-		return LogEntry{}, fmt.Errorf("inserting log entry: %w", err)
+// of returns the time of the entry.
+func (f TimeField) of(entry LogEntry) time.Time {
+	if f == ReceivedTime {
+		return entry.ReceivedAt
 	}
 
-	return entry, nil
+	return entry.Timestamp
+}
+
+// Create stores the Log entry and reports whether it's new. Entries with IDs that are
+// already stored are handled according to the duplicate policy and reported as not new.
+func (r Repository) Create(entry LogEntry) (bool, error) {
+	r.received.Inc()
+
+	if stored, err := r.db.Get(entry.ID()); err == nil {
+		return false, r.duplicate(stored, entry)
+	}
+
+	unlock := r.indexes.lock()
+
+	// The quota is checked atomically with the insert, as long as changes are serialized along with
+	// the indexes. Otherwise, e.g. in repositories without indexes, concurrent inserts may exceed it.
+	if r.settings.MaxEntries > 0 && r.db.Len() >= r.settings.MaxEntries ||
+		r.settings.MaxBytes > 0 && r.db.Bytes()+int64(entry.Size()) > r.settings.MaxBytes {
+		unlock()
+
+		return false, ErrQuotaExceeded
+	}
+
+	entry.ReceivedAt, entry.Sequence = time.Now().UTC(), r.db.NextSeq()
+
+	stored, inserted, err := r.db.InsertIfAbsent(entry)
+	if inserted {
+		r.indexes.add(entry)
+	}
+
+	unlock()
+
+	if err != nil {
+		return false, fmt.Errorf("inserting log entry: %w", err)
+	}
+
+	if !inserted {
+		// The entry has been stored concurrently since the check above.
+		return false, r.duplicate(stored, entry)
+	}
+
+	return true, nil
+}
+
+// duplicate handles the entry with the ID of the stored one according to the duplicate policy.
+func (r Repository) duplicate(stored, entry LogEntry) error {
+	switch r.settings.Duplicates {
+	case LastWriteWins:
+		r.replaced.Inc()
+
+		entry.ReceivedAt, entry.Sequence = time.Now().UTC(), r.db.NextSeq()
+
+		unlock := r.indexes.lock()
+		defer unlock()
+
+		// The stored entry may have been replaced concurrently, so the one actually replaced is unindexed.
+		replaced, loaded, err := r.db.Swap(entry)
+		if err != nil {
+			return fmt.Errorf("replacing log entry: %w", err)
+		}
+
+		if loaded {
+			r.indexes.remove(replaced)
+		}
+
+		r.indexes.add(entry)
+	case ConflictError:
+		if !sameEntry(stored, entry) {
+			r.conflicts.Inc()
+
+			return fmt.Errorf("%w: %q", ErrConflict, entry.Id)
+		}
+
+		r.ignored.Inc()
+	default:
+		r.ignored.Inc()
+	}
+
+	return nil
+}
+
+// DeleteBefore deletes entries with timestamps before the given time and returns their number.
+func (r Repository) DeleteBefore(t time.Time) int {
+	var entries []LogEntry
+
+	if r.indexes != nil {
+		entries = r.indexes.before(r.db, t)
+	} else {
+		// Find never fails for the in-memory storage.
+		entries, _ = r.db.Find(func(value LogEntry) bool {
+			return value.Timestamp.Before(t)
+		})
+	}
+
+	unlock := r.indexes.lock()
+	defer unlock()
+
+	var deleted int
+
+	for _, e := range entries {
+		// Entries replaced since they have been found are kept, as their timestamps may differ.
+		if r.db.DeleteIf(e.ID(), func(stored LogEntry) bool { return stored.Sequence == e.Sequence }) {
+			r.indexes.remove(e)
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+// sizeOfValue estimates the memory size of decoded JSON values.
+func sizeOfValue(v any) int {
+	const ifaceSize = int(unsafe.Sizeof(any(nil)))
+
+	switch v := v.(type) {
+	case string:
+		return ifaceSize + len(v)
+	case map[string]any:
+		size := ifaceSize
+		for k, item := range v {
+			size += len(k) + int(unsafe.Sizeof(k)) + sizeOfValue(item)
+		}
+
+		return size
+	case []any:
+		size := ifaceSize
+		for _, item := range v {
+			size += sizeOfValue(item)
+		}
+
+		return size
+	default:
+		return ifaceSize + 8
+	}
 }
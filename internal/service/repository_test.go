@@ -1,11 +1,18 @@
 package service
 
 import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/dyptan-io/log-management/v2/api"
 	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
 )
 
@@ -66,24 +73,39 @@ func TestRepository_Get(t *testing.T) {
 	pastTime := now.Add(-24 * time.Hour)
 	futureTime := now.Add(24 * time.Hour)
 
+	// Entries are received in reverse order of their timestamps.
 	testEntries := []LogEntry{
 		{
-			Id:        "past",
-			Message:   "Past message",
-			Severity:  "INFO",
-			Timestamp: pastTime,
+			Id:         "past",
+			Message:    "Past message",
+			Severity:   "INFO",
+			Timestamp:  pastTime,
+			ReceivedAt: futureTime,
+			Sequence:   3,
 		},
 		{
-			Id:        "present",
-			Message:   "Present message",
-			Severity:  "WARN",
-			Timestamp: now,
+			Id:         "present",
+			Message:    "Present message",
+			Severity:   "WARN",
+			Timestamp:  now,
+			ReceivedAt: now,
+			Sequence:   2,
 		},
 		{
-			Id:        "future",
-			Message:   "Future message",
-			Severity:  "ERROR",
-			Timestamp: futureTime,
+			Id:         "future",
+			Message:    "Future message",
+			Severity:   "ERROR",
+			Timestamp:  futureTime,
+			ReceivedAt: pastTime,
+			Sequence:   1,
+		},
+		{
+			Id:         "same-time",
+			Message:    "Same time message",
+			Severity:   "INFO",
+			Timestamp:  now,
+			ReceivedAt: now,
+			Sequence:   4,
 		},
 	}
 
@@ -94,9 +116,10 @@ func TestRepository_Get(t *testing.T) {
 	tests := map[string]struct {
 		giveOpts    SearchOptions
 		wantEntries []LogEntry
+		wantErr     error
 	}{
 		"get all entries": {
-			wantEntries: testEntries,
+			wantEntries: []LogEntry{testEntries[0], testEntries[1], testEntries[3], testEntries[2]},
 		},
 		"filter by from time": {
 			giveOpts: SearchOptions{
@@ -104,6 +127,7 @@ func TestRepository_Get(t *testing.T) {
 			},
 			wantEntries: []LogEntry{
 				testEntries[1], // "present"
+				testEntries[3], // "same-time"
 				testEntries[2], // "future"
 			},
 		},
@@ -114,6 +138,7 @@ func TestRepository_Get(t *testing.T) {
 			wantEntries: []LogEntry{
 				testEntries[0], // "past"
 				testEntries[1], // "present"
+				testEntries[3], // "same-time"
 			},
 		},
 		"filter by from and to time": {
@@ -121,12 +146,31 @@ func TestRepository_Get(t *testing.T) {
 				From: &pastTime,
 				To:   &futureTime,
 			},
+			wantEntries: []LogEntry{testEntries[0], testEntries[1], testEntries[3], testEntries[2]},
+		},
+		"sort descending": {
+			giveOpts: SearchOptions{
+				Descending: true,
+			},
+			wantEntries: []LogEntry{testEntries[2], testEntries[3], testEntries[1], testEntries[0]},
+		},
+		"filter by received time": {
+			giveOpts: SearchOptions{
+				From: &now,
+				Time: ReceivedTime,
+			},
 			wantEntries: []LogEntry{
-				testEntries[0], // "past"
 				testEntries[1], // "present"
-				testEntries[2], // "future"
+				testEntries[3], // "same-time"
+				testEntries[0], // "past"
 			},
 		},
+		"unknown time": {
+			giveOpts: SearchOptions{
+				Time: "created",
+			},
+			wantErr: ErrBadSearch,
+		},
 	}
 
 	for name, test := range tests {
@@ -135,35 +179,78 @@ func TestRepository_Get(t *testing.T) {
 
 			entries, err := repo.Get(test.giveOpts)
 
-			require.NoError(t, err)
-			require.ElementsMatch(t, test.wantEntries, entries)
+			require.ErrorIs(t, err, test.wantErr)
+			require.Equal(t, test.wantEntries, entries)
 		})
 	}
 }
 
+func TestRepository_CreateReceived(t *testing.T) {
+	repo := NewRepository(storage.NewInMemory[LogEntry]())
+	before := time.Now()
+
+	for _, id := range []string{"1", "2"} {
+		created, err := repo.Create(LogEntry{Id: id, Timestamp: before.Add(-time.Hour)})
+
+		require.NoError(t, err)
+		require.True(t, created)
+	}
+
+	entries, err := repo.Get(SearchOptions{From: &before, Time: ReceivedTime})
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	for i, entry := range entries {
+		require.Equal(t, int64(i+1), entry.Sequence)
+		require.WithinRange(t, entry.ReceivedAt, before, time.Now())
+	}
+}
+
 func TestRepository_Create(t *testing.T) {
-	store := storage.NewInMemory[LogEntry]()
-	repo := NewRepository(store)
+	now := time.Now()
+	stored := LogEntry{Id: "stored", Message: "Stored", Severity: "INFO", Timestamp: now}
 
 	tests := map[string]struct {
-		giveEntry LogEntry
-		wantErr   error
+		givePolicy  DuplicatePolicy
+		giveEntry   LogEntry
+		wantCreated bool
+		wantErr     error
+		wantMessage string
 	}{
 		"create valid entry": {
-			giveEntry: LogEntry{
-				Id:        "test-123",
-				Message:   "Test message",
-				Severity:  "INFO",
-				Timestamp: time.Now(),
-			},
+			giveEntry:   LogEntry{Id: "test-123", Message: "Test message", Severity: "INFO", Timestamp: now},
+			wantCreated: true,
+			wantMessage: "Test message",
 		},
 		"create entry with empty ID": {
-			giveEntry: LogEntry{
-				Message:   "No ID",
-				Severity:  "WARN",
-				Timestamp: time.Now(),
-			},
-			wantErr: storage.ErrMissingID,
+			giveEntry: LogEntry{Message: "No ID", Severity: "WARN", Timestamp: now},
+			wantErr:   storage.ErrMissingID,
+		},
+		"ignore duplicate by default": {
+			giveEntry:   LogEntry{Id: "stored", Message: "Replacement", Severity: "INFO", Timestamp: now},
+			wantMessage: "Stored",
+		},
+		"ignore duplicate if first write wins": {
+			givePolicy:  FirstWriteWins,
+			giveEntry:   LogEntry{Id: "stored", Message: "Replacement", Severity: "INFO", Timestamp: now},
+			wantMessage: "Stored",
+		},
+		"replace duplicate if last write wins": {
+			givePolicy:  LastWriteWins,
+			giveEntry:   LogEntry{Id: "stored", Message: "Replacement", Severity: "INFO", Timestamp: now},
+			wantMessage: "Replacement",
+		},
+		"reject different duplicate on conflict": {
+			givePolicy:  ConflictError,
+			giveEntry:   LogEntry{Id: "stored", Message: "Replacement", Severity: "INFO", Timestamp: now},
+			wantErr:     ErrConflict,
+			wantMessage: "Stored",
+		},
+		"ignore identical duplicate on conflict": {
+			givePolicy:  ConflictError,
+			giveEntry:   LogEntry{Id: "stored", Message: "Stored", Severity: "INFO", Timestamp: now.UTC(), Attributes: map[string]any{}},
+			wantMessage: "Stored",
 		},
 	}
 
@@ -171,19 +258,106 @@ func TestRepository_Create(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			entry, err := repo.Create(test.giveEntry)
+			store := storage.NewInMemory[LogEntry]()
+			require.NoError(t, store.Insert(stored))
+
+			repo := NewRepository(store).withSettings(TenantSettings{Duplicates: test.givePolicy})
+
+			created, err := repo.Create(test.giveEntry)
 
 			require.ErrorIs(t, err, test.wantErr)
-			require.Equal(t, test.giveEntry.Id, entry.Id)
+			require.Equal(t, test.wantCreated, created)
 
-			if test.wantErr != nil {
+			if test.wantMessage == "" {
 				return
 			}
 
-			storedEntry, err := repo.GetByID(entry.Id)
+			storedEntry, err := repo.GetByID(test.giveEntry.Id)
 
 			require.NoError(t, err)
-			require.Equal(t, entry.Id, storedEntry.Id)
+			require.Equal(t, test.wantMessage, storedEntry.Message)
+		})
+	}
+}
+
+func TestDuplicatePolicy_Validate(t *testing.T) {
+	for _, policy := range []DuplicatePolicy{"", FirstWriteWins, LastWriteWins, ConflictError} {
+		require.NoError(t, policy.Validate())
+	}
+
+	require.ErrorIs(t, DuplicatePolicy("newest").Validate(), ErrUnknownDuplicatePolicy)
+}
+func TestServer_ListLogsOrder(t *testing.T) {
+	handler := api.Handler(NewServer(
+		NewTenants(storage.NewNamespaced[LogEntry](), TenantSettings{}, nil),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	))
+
+	body := `[{"id":"late","message":"m","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}},` +
+		`{"id":"early","message":"m","severity":"Info","timestamp":"2024-01-01T00:00:00Z","attributes":{}}]`
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(body)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	tests := map[string]struct {
+		giveQuery  string
+		wantStatus int
+		wantIDs    []string
+	}{
+		"event time": {
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"early", "late"},
+		},
+		"event time descending": {
+			giveQuery:  "?order=desc",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"late", "early"},
+		},
+		"received time": {
+			giveQuery:  "?time=received",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"late", "early"},
+		},
+		"received time descending": {
+			giveQuery:  "?time=received&order=desc",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"early", "late"},
+		},
+		"unknown time": {
+			giveQuery:  "?time=created",
+			wantStatus: http.StatusBadRequest,
+		},
+		"unknown order": {
+			giveQuery:  "?order=random",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/logs"+test.giveQuery, nil))
+
+			require.Equal(t, test.wantStatus, rec.Code)
+
+			if test.wantStatus != http.StatusOK {
+				return
+			}
+
+			var logs []api.Log
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &logs))
+
+			ids := make([]string, 0, len(logs))
+
+			for _, log := range logs {
+				require.NotNil(t, log.ReceivedAt)
+				require.NotNil(t, log.Sequence)
+
+				ids = append(ids, log.Id)
+			}
+
+			require.Equal(t, test.wantIDs, ids)
 		})
 	}
 }
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/dyptan-io/log-management/v2/internal/platform/auth"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+const (
+	// TenantHeader is a header of requests identifying the tenant, if it's not bound to the API key.
+	TenantHeader = "X-Tenant"
+	// DefaultTenant is the tenant of requests that don't identify any.
+	DefaultTenant = "default"
+)
+
+var (
+	// ErrBadTenant is an error when the tenant name is malformed.
+	ErrBadTenant = errors.New("tenant must be 1-64 letters, digits, dots, dashes or underscores")
+	// ErrTenantMismatch is an error when the requested tenant differs from the one of the API key.
+	ErrTenantMismatch = errors.New("API key doesn't belong to the tenant")
+	// ErrQuotaExceeded is an error when the tenant storage quota is exhausted.
+	ErrQuotaExceeded = errors.New("tenant storage quota exceeded")
+)
+
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type (
+	// TenantSettings contains retention, quota and duplicate settings of a tenant. Zero values disable limits.
+	TenantSettings struct {
+		// Retention is how long entries are kept after their timestamp.
+		Retention time.Duration `yaml:"retention"`
+		// MaxEntries and MaxBytes limit the number and the approximate size of stored entries.
+		MaxEntries int   `yaml:"max_entries"`
+		MaxBytes   int64 `yaml:"max_bytes"`
+		// Duplicates is the policy of entries with IDs that are already stored.
+		Duplicates DuplicatePolicy `yaml:"duplicates"`
+	}
+
+	// Tenants is a set of repositories of tenants isolated from each other.
+	Tenants struct {
+		spaces  *storage.Namespaced[LogEntry]
+		metrics *Metrics
+
+		mu       sync.RWMutex
+		defaults TenantSettings
+		settings map[string]TenantSettings
+
+		// indexes are indexes of tenants, *indexes by tenant names.
+		indexes sync.Map
+	}
+)
+
+// NewTenants returns a new instance of Tenants with the defaults applied to tenants without own settings.
+func NewTenants(spaces *storage.Namespaced[LogEntry], defaults TenantSettings, settings map[string]TenantSettings) *Tenants {
+	t := &Tenants{spaces: spaces}
+	t.Update(defaults, settings)
+
+	return t
+}
+
+// WithMetrics sets metrics updated by repositories of all tenants.
+func (t *Tenants) WithMetrics(m *Metrics) *Tenants {
+	t.metrics = m
+
+	return t
+}
+
+// Update replaces settings of tenants, e.g. on configuration reload.
+func (t *Tenants) Update(defaults TenantSettings, settings map[string]TenantSettings) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.defaults, t.settings = defaults, settings
+}
+
+// Repository returns the repository of the tenant.
+func (t *Tenants) Repository(tenant string) Repository {
+	repo := NewRepository(t.spaces.Namespace(tenant)).withSettings(t.settingsOf(tenant)).withIndexes(t.indexesOf(tenant))
+	if t.metrics != nil {
+		repo = repo.WithMetrics(t.metrics)
+	}
+
+	return repo
+}
+
+// Lookup returns the repository of the tenant for reading. Unlike Repository, it doesn't create storage
+// of tenants that have never stored entries, so that reads of arbitrary tenants don't grow memory usage,
+// and returns an empty repository instead.
+func (t *Tenants) Lookup(tenant string) Repository {
+	if _, ok := t.spaces.Lookup(tenant); !ok {
+		return NewRepository(storage.NewInMemory[LogEntry]())
+	}
+
+	return t.Repository(tenant)
+}
+
+// Expire deletes entries older than retention periods of their tenants and returns their number.
+func (t *Tenants) Expire(now time.Time) int {
+	var expired int
+
+	for _, tenant := range t.spaces.Names() {
+		if retention := t.settingsOf(tenant).Retention; retention > 0 {
+			expired += t.Repository(tenant).DeleteBefore(now.Add(-retention))
+		}
+	}
+
+	return expired
+}
+
+// indexesOf returns indexes of the tenant, creating them if needed.
+func (t *Tenants) indexesOf(tenant string) *indexes {
+	if i, ok := t.indexes.Load(tenant); ok {
+		return i.(*indexes)
+	}
+
+	i, _ := t.indexes.LoadOrStore(tenant, newIndexes())
+
+	return i.(*indexes)
+}
+
+func (t *Tenants) settingsOf(tenant string) TenantSettings {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if s, ok := t.settings[tenant]; ok {
+		return s
+	}
+
+	return t.defaults
+}
+
+// tenantOf returns the tenant of the request: the one of the API key, or the one given with the
+// X-Tenant header if the key isn't bound to any tenant.
+func tenantOf(r *http.Request) (string, error) {
+	tenant := r.Header.Get(TenantHeader)
+	if tenant != "" && !tenantPattern.MatchString(tenant) {
+		return "", fmt.Errorf("%w: %q", ErrBadTenant, tenant)
+	}
+
+	if key, ok := auth.FromContext(r.Context()); ok && key.Tenant != "" {
+		if tenant != "" && tenant != key.Tenant {
+			return "", fmt.Errorf("%w: %q", ErrTenantMismatch, tenant)
+		}
+
+		return key.Tenant, nil
+	}
+
+	if tenant == "" {
+		return DefaultTenant, nil
+	}
+
+	return tenant, nil
+}
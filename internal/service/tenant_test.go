@@ -0,0 +1,129 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/auth"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestServer_Tenants(t *testing.T) {
+	store := storage.NewNamespaced[LogEntry]()
+	tenants := NewTenants(store, TenantSettings{}, map[string]TenantSettings{"small": {MaxEntries: 1}})
+	handler := api.Handler(NewServer(tenants, slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	post := func(tenant string, key *auth.Key, body string) int {
+		r := httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(body))
+		if tenant != "" {
+			r.Header.Set(TenantHeader, tenant)
+		}
+
+		if key != nil {
+			r = r.WithContext(auth.NewContext(r.Context(), *key))
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		return rec.Code
+	}
+
+	teamA := &auth.Key{Name: "a", Tenant: "team-a"}
+
+	tests := map[string]struct {
+		giveTenant string
+		giveKey    *auth.Key
+		giveBody   string
+		wantStatus int
+	}{
+		"default tenant": {
+			giveBody:   `[{"id":"1","message":"default","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}]`,
+			wantStatus: http.StatusAccepted,
+		},
+		"tenant header": {
+			giveTenant: "team-b",
+			giveBody:   `[{"id":"1","message":"team-b","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}]`,
+			wantStatus: http.StatusAccepted,
+		},
+		"tenant of API key": {
+			giveKey:    teamA,
+			giveBody:   `[{"id":"1","message":"team-a","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}]`,
+			wantStatus: http.StatusAccepted,
+		},
+		"tenant header of another API key tenant": {
+			giveTenant: "team-b",
+			giveKey:    teamA,
+			giveBody:   `[{"id":"2","message":"team-a","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}]`,
+			wantStatus: http.StatusForbidden,
+		},
+		"malformed tenant": {
+			giveTenant: "team/b",
+			giveBody:   `[{"id":"2","message":"team-b","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		"quota exceeded": {
+			giveTenant: "small",
+			giveBody:   `[{"id":"1","message":"small","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}},{"id":"2","message":"small","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}]`,
+			wantStatus: http.StatusAccepted,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.wantStatus, post(test.giveTenant, test.giveKey, test.giveBody))
+		})
+	}
+
+	for tenant, wantMessage := range map[string]string{DefaultTenant: "default", "team-a": "team-a", "team-b": "team-b"} {
+		entry, err := tenants.Repository(tenant).GetByID("1")
+		require.NoError(t, err)
+		require.Equal(t, wantMessage, entry.Message)
+	}
+
+	require.Equal(t, 1, store.Namespace("small").Len())
+
+	// Reads of unknown tenants don't create them.
+	for target, wantStatus := range map[string]int{"/v1/logs": http.StatusOK, "/v1/logs/1": http.StatusNotFound} {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.Header.Set(TenantHeader, "unknown")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		require.Equal(t, wantStatus, rec.Code, target)
+	}
+
+	require.Equal(t, []string{DefaultTenant, "small", "team-a", "team-b"}, store.Names())
+	_, ok := tenants.indexes.Load("unknown")
+	require.False(t, ok)
+}
+
+func TestTenants_Expire(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	store := storage.NewNamespaced[LogEntry]()
+	tenants := NewTenants(store, TenantSettings{Retention: time.Hour}, map[string]TenantSettings{"forever": {}})
+
+	for _, tenant := range []string{"default", "forever"} {
+		repo := tenants.Repository(tenant)
+
+		_, err := repo.Create(LogEntry{Id: "old", Timestamp: now.Add(-2 * time.Hour)})
+		require.NoError(t, err)
+
+		_, err = repo.Create(LogEntry{Id: "new", Timestamp: now.Add(-time.Minute)})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 1, tenants.Expire(now))
+	require.Equal(t, 1, store.Namespace("default").Len())
+	require.Equal(t, 2, store.Namespace("forever").Len())
+}
@@ -2,82 +2,323 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"regexp"
+	"time"
 
 	"github.com/dyptan-io/log-management/v2/api"
 	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
 )
 
-// Server implements the api.ServerInterface.
+// Server implements the api.ServerInterface. Requests are confined to repositories of their tenants,
+// and read requests are restricted by policies of roles of their API keys.
 type Server struct {
-	repo   Repository
-	logger *slog.Logger
+	tenants    *Tenants
+	policies   *Policies
+	limits     *ClientLimits
+	validator  *Validator
+	ingestions *Ingestions
+	logger     *slog.Logger
 }
 
 // NewServer return a new instance of Server.
-func NewServer(repo Repository, logger *slog.Logger) Server {
+func NewServer(tenants *Tenants, logger *slog.Logger) Server {
 	return Server{
-		repo:   repo,
-		logger: logger,
+		tenants: tenants,
+		logger:  logger,
 	}
 }
 
+// WithPolicies sets policies of roles applied to ListLogs and GetLogsById responses.
+func (s Server) WithPolicies(p *Policies) Server {
+	s.policies = p
+
+	return s
+}
+
+// WithLimits sets rate limits and quotas of clients applied to PostLog requests.
+func (s Server) WithLimits(l *ClientLimits) Server {
+	s.limits = l
+
+	return s
+}
+
+// WithValidator sets the validator of PostLog requests with their size limits. Without it, entries
+// are still validated against the API schema.
+func (s Server) WithValidator(v *Validator) Server {
+	s.validator = v
+
+	return s
+}
+
+// WithIngestions sets the store of PostLog results, which are referred to by the Location header.
+func (s Server) WithIngestions(i *Ingestions) Server {
+	s.ingestions = i
+
+	return s
+}
+
 func (Server) Health(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (s Server) ListLogs(w http.ResponseWriter, _ *http.Request, params api.ListLogsParams) {
-	entries, err := s.repo.Get(SearchOptions{
-		From: params.From,
-		To:   params.To,
-	})
+func (s Server) ListLogs(w http.ResponseWriter, r *http.Request, params api.ListLogsParams) {
+	repo, err := s.repository(r)
 	if err != nil {
 		s.handleError(w, err)
 		return
 	}
 
-	logs := make([]api.Log, len(entries))
+	policy, err := s.policies.policyOf(r)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
 
-	for i, entry := range entries {
-		logs[i] = toDto(entry)
+	opts, err := searchOptions(params)
+	if err != nil {
+		s.handleError(w, err)
+		return
 	}
 
-	writeJSON(w, logs)
+	// Entries hidden by the policy are filtered out before the limit, so that pages are full.
+	opts.Match, opts.Mask = policy.Allows, policy.Mask
+
+	if accepts(r, ndjsonContentType) {
+		if params.Limit == nil {
+			// Streamed entries aren't paged unless the limit is given.
+			opts.Limit = 0
+		}
+
+		s.streamLogs(w, r, repo, policy, opts)
+
+		return
+	}
+
+	page, err := repo.GetPage(opts)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	logs := make([]api.Log, 0, len(page.Entries))
+
+	for _, entry := range page.Entries {
+		logs = append(logs, toDto(policy.Apply(entry)))
+	}
+
+	if page.Next != nil {
+		next := page.Next.String()
+
+		query := r.URL.Query()
+		query.Set("cursor", next)
+
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, query.Encode()))
+		w.Header().Set(NextCursorHeader, next)
+	}
+
+	writeJSON(w, http.StatusOK, logs)
 }
 
-func (s Server) PostLog(w http.ResponseWriter, r *http.Request) {
-	var logs []api.Log
+// streamLogs writes entries as lines of JSON while they are read, so that large result sets aren't held
+// in memory, until the client goes away. With the limit, the cursor of the next page is sent in a trailer.
+func (s Server) streamLogs(w http.ResponseWriter, r *http.Request, repo Repository, policy Policy, opts SearchOptions) {
+	limit := opts.Limit
+	if limit > 0 {
+		// One more entry tells whether there is a next page.
+		opts.Limit++
+	}
 
-	if err := readJSON(r, &logs); err != nil {
+	entries, err := repo.Entries(r.Context(), opts)
+	if err != nil {
 		s.handleError(w, err)
 		return
 	}
 
-	for _, log := range logs {
-		if _, err := s.repo.Create(fromDto(log)); err != nil {
-			s.handleError(w, err)
+	if limit > 0 {
+		w.Header().Set("Trailer", NextCursorHeader)
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+
+	lines := newLineWriter(w)
+
+	var (
+		n    int
+		last LogEntry
+	)
+
+	for entry := range entries {
+		if n == limit && limit > 0 {
+			w.Header().Set(NextCursorHeader, cursorAt(opts, last).String())
+			break
+		}
+
+		if err := lines.Write(toDto(policy.Apply(entry))); err != nil {
+			s.logger.Debug("streaming log entries has been interrupted", "error", err)
 			return
 		}
+
+		n, last = n+1, entry
+	}
+
+	if err := lines.Flush(); err != nil {
+		s.logger.Debug("streaming log entries has been interrupted", "error", err)
+	}
+}
+
+func (s Server) PostLog(w http.ResponseWriter, r *http.Request) {
+	tenant, err := tenantOf(r)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	client := clientOf(r)
+
+	if err := s.limits.allowRequest(client); err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	if limit := s.validator.Limits().MaxBodyBytes; limit > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+
+	body := &countingReader{ReadCloser: r.Body}
+	r.Body = body
+
+	data, err := readBody(r)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	logs, err := s.validator.Decode(data)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	if err := s.limits.allowEntries(client, len(logs)); err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	if err := s.limits.allowBytes(client, body.n); err != nil {
+		s.handleError(w, err)
+		return
 	}
+
+	result := s.ingest(s.tenants.Repository(tenant), logs)
+
+	if s.ingestions != nil {
+		s.ingestions.add(tenant, result, time.Now())
+		w.Header().Set("Location", "/v1/ingestions/"+result.Id)
+	}
+
+	writeJSON(w, http.StatusAccepted, result)
 }
 
-func (s Server) GetLogsById(w http.ResponseWriter, _ *http.Request, id string) {
-	entry, err := s.repo.GetByID(id)
+func (s Server) GetIngestion(w http.ResponseWriter, r *http.Request, id string) {
+	tenant, err := tenantOf(r)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	if s.ingestions == nil {
+		s.handleError(w, storage.ErrNotFound)
+		return
+	}
+
+	result, err := s.ingestions.Get(tenant, id)
 	if err != nil {
 		s.handleError(w, err)
 		return
 	}
 
-	writeJSON(w, toDto(entry))
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s Server) GetLogsById(w http.ResponseWriter, r *http.Request, id string) {
+	repo, err := s.repository(r)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	policy, err := s.policies.policyOf(r)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	entry, err := repo.GetByID(id)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	// Entries hidden by the policy are indistinguishable from missing ones.
+	if !policy.Allows(entry) {
+		s.handleError(w, storage.ErrNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toDto(policy.Apply(entry)))
+}
+
+// ingest stores Log entries one by one, so that failures of some entries don't affect others.
+func (s Server) ingest(repo Repository, logs []api.Log) api.IngestResult {
+	result := api.IngestResult{
+		Id:         newIngestionID(),
+		Duplicates: []string{},
+		Rejected:   []api.RejectedEntry{},
+	}
+
+	for i, log := range logs {
+		created, err := repo.Create(fromDto(log))
+		if err != nil {
+			s.logger.Warn("log entry has been rejected", "error", err, "index", i)
+			result.Rejected = append(result.Rejected, api.RejectedEntry{Index: i, Reason: err.Error()})
+
+			continue
+		}
+
+		if !created {
+			result.Duplicates = append(result.Duplicates, log.Id)
+		}
+
+		result.Accepted++
+	}
+
+	return result
+}
+
+// repository returns the repository of the tenant of the request for reading.
+func (s Server) repository(r *http.Request) (Repository, error) {
+	tenant, err := tenantOf(r)
+	if err != nil {
+		return Repository{}, err
+	}
+
+	return s.tenants.Lookup(tenant), nil
 }
 
 func (s Server) handleError(w http.ResponseWriter, err error) {
 	if status := toStatus(err); status != http.StatusOK {
 		s.logger.Warn("request has failed", "error", err)
 
-		w.WriteHeader(status)
-		writeJSON(w, api.ErrorResponse{Errors: []string{err.Error()}})
+		var limitErr *LimitError
+		if errors.As(err, &limitErr) {
+			w.Header().Set("Retry-After", limitErr.retryAfter())
+		}
+
+		writeJSON(w, status, api.ErrorResponse{Errors: errorMessages(err)})
 	}
 }
 
@@ -86,10 +327,26 @@ func toStatus(err error) int {
 		return http.StatusOK
 	}
 
-	if isError(err, storage.ErrMissingID, ErrBadRequestID) {
+	if isError(err, storage.ErrMissingID, ErrBadRequestID, ErrBadTenant, ErrBadSearch, ErrMalformedBody, ErrInvalidEntry) {
 		return http.StatusBadRequest
 	}
 
+	if isError(err, ErrTenantMismatch, ErrUnknownRole) {
+		return http.StatusForbidden
+	}
+
+	if isError(err, ErrRateLimited) {
+		return http.StatusTooManyRequests
+	}
+
+	if isError(err, ErrBatchTooLarge, ErrBodyTooLarge, ErrTooManyEntries) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	if isError(err, ErrConflict) {
+		return http.StatusConflict
+	}
+
 	if isError(err, storage.ErrNotFound) {
 		return http.StatusNotFound
 	}
@@ -97,6 +354,23 @@ func toStatus(err error) int {
 	return http.StatusInternalServerError
 }
 
+// errorMessages returns messages of joined errors separately, e.g. of every invalid entry.
+func errorMessages(err error) []string {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	errs := joined.Unwrap()
+	messages := make([]string, len(errs))
+
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+
+	return messages
+}
+
 func isError(err error, targets ...error) bool {
 	for _, t := range targets {
 		if errors.Is(err, t) {
@@ -107,14 +381,111 @@ func isError(err error, targets ...error) bool {
 	return false
 }
 
+// searchOptions returns SearchOptions of ListLogs query parameters.
+func searchOptions(params api.ListLogsParams) (SearchOptions, error) {
+	opts := SearchOptions{From: params.From, To: params.To, Limit: defaultPageSize}
+
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxPageSize {
+			return SearchOptions{}, fmt.Errorf("%w: limit must be 1-%d", ErrBadSearch, maxPageSize)
+		}
+
+		opts.Limit = *params.Limit
+	}
+
+	if params.Cursor != nil {
+		after, err := ParseCursor(*params.Cursor)
+		if err != nil {
+			return SearchOptions{}, err
+		}
+
+		opts.After = &after
+	}
+
+	if params.Time != nil {
+		opts.Time = TimeField(*params.Time)
+	}
+
+	if params.Severity != nil {
+		opts.Severities = *params.Severity
+	}
+
+	if params.MinSeverity != nil {
+		opts.MinSeverity = *params.MinSeverity
+	}
+
+	if params.Message != nil {
+		opts.Message = *params.Message
+	}
+
+	if params.MessageRegex != nil {
+		pattern, err := regexp.Compile(*params.MessageRegex)
+		if err != nil {
+			return SearchOptions{}, fmt.Errorf("%w: message_regex: %v", ErrBadSearch, err)
+		}
+
+		opts.MessagePattern = pattern
+	}
+
+	if params.IdPrefix != nil {
+		opts.IDPrefix = *params.IdPrefix
+	}
+
+	if params.Text != nil {
+		opts.Text = *params.Text
+	}
+
+	if params.Q != nil {
+		match, err := CompileQuery(*params.Q)
+		if err != nil {
+			return SearchOptions{}, err
+		}
+
+		opts.Query = match
+	}
+
+	if params.Attr != nil {
+		for _, s := range *params.Attr {
+			f, err := ParseAttributeFilter(s)
+			if err != nil {
+				return SearchOptions{}, err
+			}
+
+			opts.Attributes = append(opts.Attributes, f)
+		}
+	}
+
+	if params.Order != nil {
+		switch *params.Order {
+		case api.Asc:
+		case api.Desc:
+			opts.Descending = true
+		default:
+			return SearchOptions{}, fmt.Errorf("%w: unknown order %q", ErrBadSearch, *params.Order)
+		}
+	}
+
+	return opts, nil
+}
+
 func toDto(entry LogEntry) api.Log {
-	return api.Log{
+	log := api.Log{
 		Id:         entry.Id,
 		Message:    entry.Message,
 		Severity:   entry.Severity,
 		Attributes: entry.Attributes,
 		Timestamp:  entry.Timestamp,
 	}
+
+	if !entry.ReceivedAt.IsZero() {
+		log.ReceivedAt = &entry.ReceivedAt
+	}
+
+	if entry.Sequence != 0 {
+		log.Sequence = &entry.Sequence
+	}
+
+	return log
 }
 
 func fromDto(entry api.Log) LogEntry {
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dyptan-io/log-management/v2/api"
+)
+
+var (
+	// ErrMalformedBody is an error when the request body is not a JSON array.
+	ErrMalformedBody = errors.New("request body must be a JSON array of log entries")
+	// ErrInvalidEntry is an error when a Log entry doesn't conform to the API schema or limits.
+	ErrInvalidEntry = errors.New("invalid log entry")
+	// ErrBodyTooLarge is an error when the request body exceeds the size limit.
+	ErrBodyTooLarge = errors.New("request body is too large")
+	// ErrTooManyEntries is an error when the request has more entries than allowed in a batch.
+	ErrTooManyEntries = errors.New("too many log entries in the request")
+)
+
+type (
+	// IngestLimits limit the size of ingested requests and their entries. Zero values disable limits.
+	IngestLimits struct {
+		MaxBodyBytes    int64 `yaml:"max_body_bytes"`
+		MaxBatchEntries int   `yaml:"max_batch_entries"`
+		// MaxAttributes is the max number of attributes of an entry, including nested ones.
+		MaxAttributes int `yaml:"max_attributes"`
+		// MaxAttributeDepth is the max nesting of attribute objects and lists, 1 for flat attributes.
+		MaxAttributeDepth int `yaml:"max_attribute_depth"`
+	}
+
+	// Validator decodes ingested Log entries and validates them against the API schema and limits.
+	Validator struct {
+		mu     sync.RWMutex
+		limits IngestLimits
+	}
+
+	// EntryError is an error of the Log entry at the index of the request. It matches ErrInvalidEntry.
+	EntryError struct {
+		Index int
+		Err   error
+	}
+)
+
+// NewValidator returns a new instance of Validator.
+func NewValidator(limits IngestLimits) *Validator {
+	v := &Validator{}
+	v.Update(limits)
+
+	return v
+}
+
+// Update replaces limits, e.g. on configuration reload.
+func (v *Validator) Update(limits IngestLimits) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.limits = limits
+}
+
+// Limits returns the current limits. Nil Validator has no limits.
+func (v *Validator) Limits() IngestLimits {
+	if v == nil {
+		return IngestLimits{}
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.limits
+}
+
+// Decode decodes the JSON array of Log entries. Entries not conforming to the schema or limits
+// are reported with their indexes as joined EntryError errors.
+func (v *Validator) Decode(body []byte) ([]api.Log, error) {
+	limits := v.Limits()
+
+	var raws []json.RawMessage
+
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedBody, err)
+	}
+
+	if limits.MaxBatchEntries > 0 && len(raws) > limits.MaxBatchEntries {
+		return nil, fmt.Errorf("%w: %d entries, at most %d allowed", ErrTooManyEntries, len(raws), limits.MaxBatchEntries)
+	}
+
+	logs := make([]api.Log, len(raws))
+
+	var errs []error
+
+	for i, raw := range raws {
+		log, err := decodeLog(raw, limits)
+		if err != nil {
+			errs = append(errs, &EntryError{Index: i, Err: err})
+			continue
+		}
+
+		logs[i] = log
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return logs, nil
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("entries[%d]: %s", e.Index, e.Reason())
+}
+
+// Reason returns all problems of the entry in a single line.
+func (e *EntryError) Reason() string {
+	return strings.ReplaceAll(e.Err.Error(), "\n", "; ")
+}
+
+// Is makes EntryError match ErrInvalidEntry.
+func (e *EntryError) Is(target error) bool {
+	return target == ErrInvalidEntry
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// decodeLog decodes a Log entry and reports all its invalid fields as joined errors.
+func decodeLog(raw json.RawMessage, limits IngestLimits) (api.Log, error) {
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		return api.Log{}, errors.New("must be an object")
+	}
+
+	var (
+		log  api.Log
+		errs []error
+	)
+
+	required := []struct {
+		name string
+		dest any
+		kind string
+	}{
+		{name: "id", dest: &log.Id, kind: "a string"},
+		{name: "message", dest: &log.Message, kind: "a string"},
+		{name: "severity", dest: &log.Severity, kind: "a string"},
+		{name: "timestamp", dest: &log.Timestamp, kind: "an RFC 3339 date-time"},
+	}
+
+	for _, f := range required {
+		value, ok := fields[f.name]
+		if !ok || isNull(value) {
+			errs = append(errs, fmt.Errorf("%s: required", f.name))
+			continue
+		}
+
+		if err := json.Unmarshal(value, f.dest); err != nil {
+			errs = append(errs, fmt.Errorf("%s: must be %s", f.name, f.kind))
+		}
+	}
+
+	if _, ok := fields["id"]; ok && log.Id == "" && len(errs) == 0 {
+		errs = append(errs, errors.New("id: must not be empty"))
+	}
+
+	// Attributes may be null, as empty maps are encoded by Go clients.
+	if value, ok := fields["attributes"]; !ok {
+		errs = append(errs, errors.New("attributes: required"))
+	} else if err := json.Unmarshal(value, &log.Attributes); err != nil {
+		errs = append(errs, errors.New("attributes: must be an object"))
+	} else if err := checkAttributes(log.Attributes, limits); err != nil {
+		errs = append(errs, err)
+	}
+
+	return log, errors.Join(errs...)
+}
+
+// checkAttributes checks the number and the nesting depth of attributes.
+func checkAttributes(attrs map[string]any, limits IngestLimits) error {
+	count, depth := measure(attrs)
+
+	var errs []error
+
+	if limits.MaxAttributes > 0 && count > limits.MaxAttributes {
+		errs = append(errs, fmt.Errorf("attributes: %d attributes, at most %d allowed", count, limits.MaxAttributes))
+	}
+
+	if limits.MaxAttributeDepth > 0 && depth > limits.MaxAttributeDepth {
+		errs = append(errs, fmt.Errorf("attributes: nested %d levels deep, at most %d allowed", depth, limits.MaxAttributeDepth))
+	}
+
+	return errors.Join(errs...)
+}
+
+// measure returns the number of attributes, including nested ones, and the nesting depth of the value.
+func measure(v any) (int, int) {
+	var count, depth int
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, item := range v {
+			c, d := measure(item)
+			count, depth = count+c+1, max(depth, d)
+		}
+	case []any:
+		for _, item := range v {
+			c, d := measure(item)
+			count, depth = count+c, max(depth, d)
+		}
+	default:
+		return 0, 0
+	}
+
+	return count, depth + 1
+}
+
+func isNull(value json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+}
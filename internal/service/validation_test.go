@@ -0,0 +1,121 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dyptan-io/log-management/v2/api"
+	"github.com/dyptan-io/log-management/v2/internal/platform/storage"
+)
+
+func TestValidator_Decode(t *testing.T) {
+	const valid = `{"id":"1","message":"m","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{"a":1}}`
+
+	tests := map[string]struct {
+		giveLimits IngestLimits
+		giveBody   string
+		wantLogs   int
+		wantErr    error
+		wantErrors []string
+	}{
+		"valid entries": {
+			giveBody: `[` + valid + `,{"id":"2","message":"","severity":"","timestamp":"0001-01-01T00:00:00Z","attributes":null}]`,
+			wantLogs: 2,
+		},
+		"not an array": {
+			giveBody: valid,
+			wantErr:  ErrMalformedBody,
+		},
+		"invalid entries": {
+			giveBody: `[` + valid + `,{"id":"2"},{"id":"","message":1,"severity":"Info","timestamp":"yesterday","attributes":[]},"entry"]`,
+			wantErr:  ErrInvalidEntry,
+			wantErrors: []string{
+				"entries[1]: message: required; severity: required; timestamp: required; attributes: required",
+				"entries[2]: message: must be a string; timestamp: must be an RFC 3339 date-time; attributes: must be an object",
+				"entries[3]: must be an object",
+			},
+		},
+		"empty ID": {
+			giveBody:   `[{"id":"","message":"m","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{}}]`,
+			wantErr:    ErrInvalidEntry,
+			wantErrors: []string{"entries[0]: id: must not be empty"},
+		},
+		"too many entries": {
+			giveLimits: IngestLimits{MaxBatchEntries: 1},
+			giveBody:   `[` + valid + `,` + valid + `]`,
+			wantErr:    ErrTooManyEntries,
+		},
+		"too many attributes": {
+			giveLimits: IngestLimits{MaxAttributes: 2},
+			giveBody:   `[{"id":"1","message":"m","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{"a":{"b":1,"c":2}}}]`,
+			wantErr:    ErrInvalidEntry,
+			wantErrors: []string{"entries[0]: attributes: 3 attributes, at most 2 allowed"},
+		},
+		"too deep attributes": {
+			giveLimits: IngestLimits{MaxAttributeDepth: 2},
+			giveBody:   `[{"id":"1","message":"m","severity":"Info","timestamp":"2024-01-02T00:00:00Z","attributes":{"a":{"b":[1]}}}]`,
+			wantErr:    ErrInvalidEntry,
+			wantErrors: []string{"entries[0]: attributes: nested 3 levels deep, at most 2 allowed"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logs, err := NewValidator(test.giveLimits).Decode([]byte(test.giveBody))
+
+			require.ErrorIs(t, err, test.wantErr)
+			require.Len(t, logs, test.wantLogs)
+
+			if test.wantErrors != nil {
+				require.Equal(t, test.wantErrors, errorMessages(err))
+			}
+		})
+	}
+}
+
+func TestServer_PostLogValidation(t *testing.T) {
+	store := storage.NewNamespaced[LogEntry]()
+	tenants := NewTenants(store, TenantSettings{}, nil)
+	server := NewServer(tenants, slog.New(slog.NewTextHandler(io.Discard, nil))).
+		WithValidator(NewValidator(IngestLimits{MaxBodyBytes: 200}))
+	handler := api.Handler(server)
+
+	tests := map[string]struct {
+		giveBody   string
+		wantStatus int
+		wantErrors int
+	}{
+		"invalid entries": {
+			giveBody:   `[{"id":"1"},{"id":"2"}]`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: 2,
+		},
+		"body too large": {
+			giveBody:   `[` + strings.Repeat(`{"id":"1"},`, 20) + `{"id":"1"}]`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantErrors: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(test.giveBody)))
+
+			require.Equal(t, test.wantStatus, rec.Code)
+
+			var resp api.ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Len(t, resp.Errors, test.wantErrors)
+		})
+	}
+
+	require.Zero(t, store.Len())
+}